@@ -0,0 +1,96 @@
+package automaton
+
+//* -------------------------
+//* ACTIVE REGION
+//* -------------------------
+// A cell can only change if something within reach of it changed in the generation before,
+// so a tracking grid only convolves the neighbourhoods of the cells that changed last time
+
+func (g *Grid) ActiveTracking() bool {
+	return g.tracking
+}
+
+// Results are the same either way, tracking just skips the cells that can not change
+func (g *Grid) SetActiveTracking(tracking bool) {
+	g.tracking = tracking
+	g.forgetChanges()
+}
+
+// Returns the coords of every cell the last convolution visited
+func (g *Grid) ActiveRegion() []Point {
+	return g.activeRegion
+}
+
+// Returns the cells the next convolution has to visit, and whether that is only part of the grid
+// The whole grid is visited when changes are not tracked or unknown, when the rule brings empty neighbourhoods to life,
+// or when it is stochastic
+func (g *Grid) activeCells(conv Convolver) ([]Point, bool) {
+	if !g.tracking || g.changed == nil || IsStochastic(conv) || bringsEmptyToLife(conv) {
+		return nil, false
+	}
+
+	reach := conv.Size() >> 1
+	visited := make(map[Point]bool)
+	var cells []Point
+
+	for coords := range g.changed {
+		for x := coords.X - reach; x <= coords.X+reach; x++ {
+			for y := coords.Y - reach; y <= coords.Y+reach; y++ {
+				// Neighbours past the edges are the cells the boundary maps them to
+				p := Point{X: x, Y: y}
+				if !g.data.InBounds(p) {
+					var ok bool
+					if p, ok = g.boundary.resolve(p, g.width, g.height); !ok {
+						continue
+					}
+				}
+
+				if visited[p] {
+					continue
+				}
+
+				visited[p] = true
+				cells = append(cells, p)
+			}
+		}
+	}
+
+	sortPoints(cells)
+
+	return cells, true
+}
+
+func (g *Grid) markChanged(coords Point) {
+	if g.tracking && g.changed != nil {
+		g.changed[coords] = true
+	}
+}
+
+func (g *Grid) rememberChanges(changed []Point) {
+	if !g.tracking {
+		return
+	}
+
+	g.changed = make(map[Point]bool, len(changed))
+	for _, coords := range changed {
+		g.changed[coords] = true
+	}
+}
+
+// The next convolution has to visit every cell
+func (g *Grid) forgetChanges() {
+	g.changed = nil
+}
+
+// Whether the rule gives a value to a cell with an entirely empty window
+func bringsEmptyToLife(conv Convolver) bool {
+	size := conv.Size()
+	matrix := make([][]*Dot, size)
+	for x := range matrix {
+		matrix[x] = make([]*Dot, size)
+	}
+
+	win := &Window{center: Point{}, size: size, matrix: matrix}
+
+	return conv.ApplyKernel(win) != nil
+}
@@ -0,0 +1,76 @@
+package automaton
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestActiveTrackingMatchesFullConvolution(t *testing.T) {
+	// B0 rules and noise bring empty neighbourhoods to life, so they need the whole grid every time
+	rules := append([]string{"B0/S8", "B03/S23", "B3/S23:noise=0.01"}, convolveTestRules...)
+
+	for _, rule := range rules {
+		for _, boundary := range allBoundaries {
+			t.Run(fmt.Sprintf("%s/%v", rule, boundary), func(t *testing.T) {
+				conv := mustParseRule(t, rule)
+
+				full, tracked := NewGrid(21, 19), NewGrid(21, 19)
+				for _, grid := range []*Grid{full, tracked} {
+					grid.SetBoundary(boundary)
+					randomSoup(grid, 2, 0.3)
+				}
+				tracked.SetActiveTracking(true)
+
+				for generation := 1; generation <= 20; generation++ {
+					// Cells painted and erased in between generations have to wake their neighbourhoods up
+					switch generation {
+					case 5:
+						for _, grid := range []*Grid{full, tracked} {
+							grid.Remove(Point{X: 10, Y: 9})
+							NewDot(Point{X: 0, Y: 0}, grid)
+							NewDot(Point{X: 20, Y: 18}, grid)
+						}
+					case 12:
+						for _, grid := range []*Grid{full, tracked} {
+							grid.ForEach(func(dot *Dot) {
+								if dot.Position().X == 3 {
+									dot.Remove()
+								}
+							})
+							NewDot(Point{X: 15, Y: 0}, grid)
+						}
+					}
+
+					full.Convolve(conv)
+					tracked.Convolve(conv)
+
+					compareGrids(t, generation, full, tracked)
+				}
+			})
+		}
+	}
+}
+
+func TestActiveTrackingSkipsStillCells(t *testing.T) {
+	grid := NewGrid(20, 20)
+	grid.SetActiveTracking(true)
+
+	// A blinker only ever changes the cells around it
+	for _, coords := range []Point{{X: 4, Y: 5}, {X: 5, Y: 5}, {X: 6, Y: 5}} {
+		NewDot(coords, grid)
+	}
+
+	conv := mustParseRule(t, "B3/S23")
+	grid.Convolve(conv)
+	grid.Convolve(conv)
+
+	if visited := len(grid.ActiveRegion()); visited >= 20*20 {
+		t.Fatalf("visited %d cells, expected only the neighbourhood of the blinker", visited)
+	}
+
+	// Birth from nothing can happen anywhere
+	grid.Convolve(mustParseRule(t, "B0/S8"))
+	if visited := len(grid.ActiveRegion()); visited != 20*20 {
+		t.Fatalf("visited %d cells with a B0 rule, expected all %d", visited, 20*20)
+	}
+}
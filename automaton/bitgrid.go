@@ -0,0 +1,303 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+//* -------------------------
+//* BIT GRID
+//* -------------------------
+// Bounded grid that stores every cell as a single bit, 64 to a word, and steps outer-totalistic rules
+// (where only the center and the number of live neighbours matter) on a whole word at a time
+type BitGrid struct {
+	width, height int
+	stride        int      // Words per row
+	cells         []uint64 // Row by row, bit x%64 of word x/64 in a row is the cell at x
+	next          []uint64 // Reused for every Step
+	birth         [9]bool  // Indexed by number of live neighbours
+	survival      [9]bool
+	boundary      Boundary
+}
+
+// Fails if the rule is not outer-totalistic, since counting is all a BitGrid can do
+func NewBitGrid(width, height int, conv Convolver) (*BitGrid, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("bit grid 'width' and 'height' must be at least 1, got %dx%d", width, height)
+	}
+
+	lut, err := CompileLUT(conv)
+	if err != nil {
+		return nil, err
+	}
+
+	birth, survival, err := outerTotalistic(lut.table)
+	if err != nil {
+		return nil, err
+	}
+
+	stride := (width + 63) / 64
+
+	return &BitGrid{
+		width:    width,
+		height:   height,
+		stride:   stride,
+		cells:    make([]uint64, stride*height),
+		next:     make([]uint64, stride*height),
+		birth:    birth,
+		survival: survival,
+	}, nil
+}
+
+func (b *BitGrid) String() string {
+	return fmt.Sprintf("BitGrid{ width: %d, height: %d, population: %d }", b.width, b.height, b.Population())
+}
+
+func (b *BitGrid) Width() int {
+	return b.width
+}
+
+func (b *BitGrid) Height() int {
+	return b.height
+}
+
+func (b *BitGrid) Boundary() Boundary {
+	return b.boundary
+}
+
+// Only Bounded and Torus can be done with whole words
+func (b *BitGrid) SetBoundary(boundary Boundary) error {
+	if boundary != Bounded && boundary != Torus {
+		return fmt.Errorf("bit grids only support the %v and %v boundaries, not %v", Bounded, Torus, boundary)
+	}
+
+	b.boundary = boundary
+
+	return nil
+}
+
+func (b *BitGrid) Clear() {
+	for i := range b.cells {
+		b.cells[i] = 0
+	}
+}
+
+func (b *BitGrid) InBounds(coords Point) bool {
+	return between(coords.X, 0, b.width-1) && between(coords.Y, 0, b.height-1)
+}
+
+// Cells outside the grid are never alive
+func (b *BitGrid) Get(coords Point) bool {
+	if !b.InBounds(coords) {
+		return false
+	}
+
+	return b.cells[coords.Y*b.stride+coords.X/64]&(1<<(coords.X%64)) != 0
+}
+
+// Cells outside the grid are ignored
+func (b *BitGrid) Set(coords Point, alive bool) {
+	if !b.InBounds(coords) {
+		return
+	}
+
+	i, bit := coords.Y*b.stride+coords.X/64, uint64(1)<<(coords.X%64)
+
+	if alive {
+		b.cells[i] |= bit
+	} else {
+		b.cells[i] &^= bit
+	}
+}
+
+func (b *BitGrid) Population() int {
+	var population int
+	for _, word := range b.cells {
+		population += bits.OnesCount64(word)
+	}
+
+	return population
+}
+
+// Calls callback with the coords of every live cell, column by column like Grid.ForEach
+func (b *BitGrid) ForEachAlive(callback func(coords Point)) {
+	for x := 0; x < b.width; x++ {
+		for y := 0; y < b.height; y++ {
+			if b.cells[y*b.stride+x/64]&(1<<(x%64)) != 0 {
+				callback(Point{X: x, Y: y})
+			}
+		}
+	}
+}
+
+// Replaces every cell with the dots of the grid, which must be the same size
+func (b *BitGrid) Load(grid *Grid) error {
+	if grid.Unbounded() || grid.Width() != b.width || grid.Height() != b.height {
+		return errors.New("can only load grids of the same size as the bit grid")
+	}
+
+	b.Clear()
+	grid.ForEach(func(dot *Dot) {
+		b.Set(dot.Position(), true)
+	})
+
+	return nil
+}
+
+// Replaces every dot of the grid with the live cells, the grid must be the same size
+func (b *BitGrid) Store(grid *Grid) error {
+	if grid.Unbounded() || grid.Width() != b.width || grid.Height() != b.height {
+		return errors.New("can only store into grids of the same size as the bit grid")
+	}
+
+	grid.Clear()
+	b.ForEachAlive(func(coords Point) {
+		NewDot(coords, grid)
+	})
+
+	return nil
+}
+
+// Advances every cell one generation
+func (b *BitGrid) Step() {
+	// Neighbour bits for a row, lined up with the cells of the row being computed
+	west, east := make([]uint64, b.stride), make([]uint64, b.stride)
+	northWest, north, northEast := make([]uint64, b.stride), make([]uint64, b.stride), make([]uint64, b.stride)
+	southWest, south, southEast := make([]uint64, b.stride), make([]uint64, b.stride), make([]uint64, b.stride)
+
+	// Turn the rule into one mask per neighbour count, for live and dead centers
+	var birthMasks, survivalMasks [9]uint64
+	for count := range birthMasks {
+		if b.birth[count] {
+			birthMasks[count] = ^uint64(0)
+		}
+		if b.survival[count] {
+			survivalMasks[count] = ^uint64(0)
+		}
+	}
+
+	for y := 0; y < b.height; y++ {
+		row := b.row(y)
+
+		b.shiftRow(b.row(y-1), northWest, northEast)
+		copy(north, b.row(y-1))
+		b.shiftRow(row, west, east)
+		b.shiftRow(b.row(y+1), southWest, southEast)
+		copy(south, b.row(y+1))
+
+		out := b.next[y*b.stride : (y+1)*b.stride]
+
+		for i, center := range row {
+			// Bit-parallel count of the 8 neighbours, as a 4 bit number spread over 4 words
+			var s0, s1, s2, s3 uint64
+			for _, neighbour := range [8]uint64{northWest[i], north[i], northEast[i], west[i], east[i], southWest[i], south[i], southEast[i]} {
+				carry0 := s0 & neighbour
+				s0 ^= neighbour
+				carry1 := s1 & carry0
+				s1 ^= carry0
+				carry2 := s2 & carry1
+				s2 ^= carry1
+				s3 ^= carry2
+			}
+
+			var result uint64
+			for count := 0; count <= 8; count++ {
+				equal := pick(s0, count&1 != 0) & pick(s1, count&2 != 0) & pick(s2, count&4 != 0) & pick(s3, count&8 != 0)
+				result |= equal & ((center & survivalMasks[count]) | (^center & birthMasks[count]))
+			}
+
+			out[i] = result
+		}
+
+		// Bits past the last column must stay dead, or they would leak into the row next time
+		out[b.stride-1] &= b.lastWordMask()
+	}
+
+	b.cells, b.next = b.next, b.cells
+}
+
+// Returns the words of row y, following the boundary for rows outside the grid
+// Rows that are outside a bounded grid are all dead
+func (b *BitGrid) row(y int) []uint64 {
+	if !between(y, 0, b.height-1) {
+		if b.boundary != Torus {
+			return make([]uint64, b.stride)
+		}
+
+		y = mod(y, b.height)
+	}
+
+	return b.cells[y*b.stride : (y+1)*b.stride]
+}
+
+// Fills west with every cell's western neighbour and east with its eastern neighbour
+func (b *BitGrid) shiftRow(row, west, east []uint64) {
+	last := b.stride - 1
+
+	// Cells coming in from past the edges
+	var fromWest, fromEast uint64
+	if b.boundary == Torus {
+		fromWest = (row[last] >> ((b.width - 1) % 64)) & 1
+		fromEast = row[0] & 1
+	}
+
+	for i, word := range row {
+		west[i] = word << 1
+		if i > 0 {
+			west[i] |= row[i-1] >> 63
+		} else {
+			west[i] |= fromWest
+		}
+
+		east[i] = word >> 1
+		if i < last {
+			east[i] |= row[i+1] << 63
+		}
+	}
+
+	east[last] |= fromEast << ((b.width - 1) % 64)
+}
+
+// Has a bit set for every column that exists in the last word of a row
+func (b *BitGrid) lastWordMask() uint64 {
+	if b.width%64 == 0 {
+		return ^uint64(0)
+	}
+
+	return uint64(1)<<(b.width%64) - 1
+}
+
+// Returns word if set, otherwise its inverse
+func pick(word uint64, set bool) uint64 {
+	if set {
+		return word
+	}
+
+	return ^word
+}
+
+// Reads birth and survival counts from a compiled rule table
+// Fails if any two neighbourhoods with the same center and count give different results
+func outerTotalistic(table [LUT_SIZE]bool) (birth, survival [9]bool, err error) {
+	var seen [2][9]bool
+
+	for neighbourhood, alive := range table {
+		center := (neighbourhood >> 4) & 1
+		count := bits.OnesCount(uint(neighbourhood)) - center
+
+		results := &birth
+		if center == 1 {
+			results = &survival
+		}
+
+		if seen[center][count] && results[count] != alive {
+			return birth, survival, errors.New("rule is not outer-totalistic, its result depends on more than the center and the number of live neighbours")
+		}
+
+		seen[center][count] = true
+		results[count] = alive
+	}
+
+	return birth, survival, nil
+}
@@ -0,0 +1,153 @@
+package automaton
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Fills a grid with a random soup, the same for the same seed
+func randomSoup(grid *Grid, seed int64, density float64) {
+	rng := rand.New(rand.NewSource(seed))
+	min, max := grid.Extent()
+
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			if rng.Float64() < density {
+				NewDot(Point{X: x, Y: y}, grid)
+			}
+		}
+	}
+}
+
+func mustParseRule(t testing.TB, rule string) Convolver {
+	t.Helper()
+
+	conv, err := ParseRule(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conv
+}
+
+func TestBitGridMatchesGrid(t *testing.T) {
+	for _, rule := range []string{"B3/S23", "B36/S23", "B0/S8"} {
+		for _, boundary := range []Boundary{Bounded, Torus} {
+			for _, width := range []int{5, 63, 64, 65, 130} {
+				t.Run(fmt.Sprintf("%s/%v/%d", rule, boundary, width), func(t *testing.T) {
+					conv := mustParseRule(t, rule)
+
+					grid := NewGrid(width, 9)
+					grid.SetBoundary(boundary)
+					randomSoup(grid, int64(width), 0.4)
+
+					bitGrid, err := NewBitGrid(width, 9, conv)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if err := bitGrid.SetBoundary(boundary); err != nil {
+						t.Fatal(err)
+					}
+					if err := bitGrid.Load(grid); err != nil {
+						t.Fatal(err)
+					}
+
+					for generation := 1; generation <= 20; generation++ {
+						grid.Convolve(conv)
+						bitGrid.Step()
+
+						for x := 0; x < width; x++ {
+							for y := 0; y < 9; y++ {
+								coords := Point{X: x, Y: y}
+								if alive := grid.Lookup(coords) != nil; bitGrid.Get(coords) != alive {
+									t.Fatalf("generation %d: cell %v is %v in the grid, but not in the bit grid", generation, coords, alive)
+								}
+							}
+						}
+
+						if bitGrid.Population() != grid.NumUsedCells() {
+							t.Fatalf("generation %d: bit grid population is %d, grid has %d cells", generation, bitGrid.Population(), grid.NumUsedCells())
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestBitGridStore(t *testing.T) {
+	grid := NewGrid(65, 9)
+	randomSoup(grid, 1, 0.4)
+
+	bitGrid, err := NewBitGrid(65, 9, mustParseRule(t, "B3/S23"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bitGrid.Load(grid); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := NewGrid(65, 9)
+	if err := bitGrid.Store(stored); err != nil {
+		t.Fatal(err)
+	}
+
+	for x := 0; x < 65; x++ {
+		for y := 0; y < 9; y++ {
+			coords := Point{X: x, Y: y}
+			if (grid.Lookup(coords) != nil) != (stored.Lookup(coords) != nil) {
+				t.Fatalf("cell %v changed from loading and storing", coords)
+			}
+		}
+	}
+
+	if stored.NumUsedCells() != grid.NumUsedCells() {
+		t.Fatalf("stored %d cells, expected %d", stored.NumUsedCells(), grid.NumUsedCells())
+	}
+}
+
+func TestBitGridRejectsOtherRules(t *testing.T) {
+	if _, err := NewBitGrid(8, 8, mustParseRule(t, "B2-a/S12")); err == nil {
+		t.Fatal("expected an error for a rule that is not outer-totalistic")
+	}
+
+	bitGrid, err := NewBitGrid(8, 8, mustParseRule(t, "B3/S23"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bitGrid.SetBoundary(KleinBottle); err == nil {
+		t.Fatal("expected an error for a boundary other than bounded or torus")
+	}
+}
+
+// Compare with BenchmarkGridConvolve, the bit grid should step the same grid around 100 times faster
+func BenchmarkBitGridStep(b *testing.B) {
+	grid := NewGrid(512, 512)
+	randomSoup(grid, 1, 0.3)
+
+	bitGrid, err := NewBitGrid(512, 512, mustParseRule(b, "B3/S23"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := bitGrid.Load(grid); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitGrid.Step()
+	}
+}
+
+func BenchmarkGridConvolve(b *testing.B) {
+	conv := mustParseRule(b, "B3/S23")
+
+	grid := NewGrid(512, 512)
+	randomSoup(grid, 1, 0.3)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		grid.Convolve(conv)
+	}
+}
@@ -0,0 +1,98 @@
+package automaton
+
+import (
+	"fmt"
+	"strings"
+)
+
+//* -------------------------
+//* BOUNDARY
+//* -------------------------
+// Decides what a Window sees when it reaches past the edges of the grid
+type Boundary int
+
+const (
+	Bounded     Boundary = iota // Everything outside the grid is empty
+	Torus                       // Both axes wrap around
+	KleinBottle                 // Both axes wrap around, but crossing the top or bottom edge mirrors x
+	Cylinder                    // x wraps around, y is bounded
+	Reflective                  // Cells outside the grid mirror the cells inside it, starting with the edge cells
+	AliveBorder                 // Everything outside the grid is permanently alive
+)
+
+var boundaryNames = map[Boundary]string{
+	Bounded:     "bounded",
+	Torus:       "torus",
+	KleinBottle: "klein",
+	Cylinder:    "cylinder",
+	Reflective:  "reflect",
+	AliveBorder: "alive",
+}
+
+func (b Boundary) String() string {
+	if name, ok := boundaryNames[b]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Boundary(%d)", int(b))
+}
+
+// Returns the boundary that comes after this one, looping around to Bounded after the last one
+func (b Boundary) Next() Boundary {
+	return (b + 1) % Boundary(len(boundaryNames))
+}
+
+func ParseBoundary(name string) (Boundary, error) {
+	for boundary, boundaryName := range boundaryNames {
+		if strings.EqualFold(name, boundaryName) {
+			return boundary, nil
+		}
+	}
+
+	return Bounded, fmt.Errorf("unknown boundary %q", name)
+}
+
+// Maps coords outside of the grid onto the cell they refer to with the given boundary
+// ok is false if the coords do not refer to any cell in the grid
+func (b Boundary) resolve(coords Point, width, height int) (resolved Point, ok bool) {
+	x, y := coords.Coords()
+
+	if between(x, 0, width-1) && between(y, 0, height-1) {
+		return coords, true
+	}
+
+	switch b {
+	case Torus:
+		return Point{X: mod(x, width), Y: mod(y, height)}, true
+
+	case KleinBottle:
+		// Every crossing of the top or bottom edge flips the grid horizontally
+		if floorDiv(y, height)%2 != 0 {
+			x = width - 1 - x
+		}
+
+		return Point{X: mod(x, width), Y: mod(y, height)}, true
+
+	case Cylinder:
+		if !between(y, 0, height-1) {
+			return coords, false
+		}
+
+		return Point{X: mod(x, width), Y: y}, true
+
+	case Reflective:
+		return Point{X: reflect(x, width), Y: reflect(y, height)}, true
+	}
+
+	return coords, false
+}
+
+// Mirrors num back into 0 - size-1, so -1 becomes 0, -2 becomes 1 and size becomes size-1
+func reflect(num, size int) int {
+	num = mod(num, 2*size)
+	if num >= size {
+		num = 2*size - 1 - num
+	}
+
+	return num
+}
@@ -0,0 +1,60 @@
+package automaton
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+//* -------------------------
+//* COLORMAP
+//* -------------------------
+// Evenly spaced colour stops from a value of 0 to 1, for drawing continuous cells
+type Colormap []color.RGBA
+
+var Colormaps = map[string]Colormap{
+	"viridis": NewColormap("#440154", "#482878", "#3e4989", "#31688e", "#26828e", "#1f9e89", "#35b779", "#6ece58", "#b5de2b", "#fde725"),
+	"inferno": NewColormap("#000004", "#1b0c41", "#4a0c6b", "#781c6d", "#a52c60", "#cf4446", "#ed6925", "#fb9b06", "#f7d13d", "#fcffa4"),
+	"gray":    NewColormap("#000000", "#adb5bd"),
+}
+
+func NewColormap(hexStops ...string) Colormap {
+	colormap := make(Colormap, len(hexStops))
+	for i, hex := range hexStops {
+		colormap[i] = mustParseHexColor(hex)
+	}
+
+	return colormap
+}
+
+// Returns one of the Colormaps by name (case insensitive)
+func ParseColormap(name string) (Colormap, error) {
+	colormap, ok := Colormaps[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown colormap %q, expected viridis, inferno or gray", name)
+	}
+
+	return colormap, nil
+}
+
+// Returns the colour of a value between 0 and 1, blended between the two closest stops
+func (c Colormap) At(value float64) color.RGBA {
+	if len(c) == 1 {
+		return c[0]
+	}
+
+	position := clampUnit(value) * float64(len(c)-1)
+	stop := int(position)
+	if stop >= len(c)-1 {
+		return c[len(c)-1]
+	}
+
+	t := position - float64(stop)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+	}
+
+	from, to := c[stop], c[stop+1]
+
+	return color.RGBA{R: lerp(from.R, to.R), G: lerp(from.G, to.G), B: lerp(from.B, to.B), A: lerp(from.A, to.A)}
+}
@@ -0,0 +1,257 @@
+package automaton
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+)
+
+const (
+	FFT_MIN_KERNEL_RADIUS = 6 // Kernels this big are convolved with FFTs instead of directly
+)
+
+// Continuous rules see their cells through one or more kernels, and update every cell from the potentials
+type ContinuousRule interface {
+	Kernels() []*ContinuousKernel
+	Update(value float64, potentials []float64) float64 // potentials has one value per kernel, the result is clamped to [0, 1]
+}
+
+//* -------------------------
+//* CONTINUOUS GRID
+//* -------------------------
+// Grid where every cell holds a value between 0 and 1 instead of a Dot, for rules like Lenia and SmoothLife
+// The edges always wrap around (a torus), since that is what FFT convolution does
+type ContinuousGrid struct {
+	width, height int
+	cells         []float64 // Indexed [x*height+y], like the columns of a ScreenPixelMatrix
+
+	planX, planY *fftPlan
+	spectra      map[*ContinuousKernel][]complex128 // Transform of every kernel convolved with FFTs, at this grid's size
+
+	seed int64
+	rng  *rand.Rand // Seeded with seed, for painting random values
+}
+
+func NewContinuousGrid(width, height int) *ContinuousGrid {
+	if width < 1 || height < 1 {
+		log.Fatalf("grid 'width' and 'height' must be at least 1, got %dx%d", width, height)
+	}
+
+	g := &ContinuousGrid{
+		width:   width,
+		height:  height,
+		cells:   make([]float64, width*height),
+		planX:   newFFTPlan(width),
+		planY:   newFFTPlan(height),
+		spectra: make(map[*ContinuousKernel][]complex128),
+	}
+	g.SetSeed(DEFAULT_SEED)
+
+	return g
+}
+
+func (g *ContinuousGrid) String() string {
+	return fmt.Sprintf("ContinuousGrid{ width: %d, height: %d, mass: %.2f }", g.width, g.height, g.Mass())
+}
+
+func (g *ContinuousGrid) Width() int {
+	return g.width
+}
+
+func (g *ContinuousGrid) Height() int {
+	return g.height
+}
+
+// Coords past the edges wrap around
+func (g *ContinuousGrid) Get(coords Point) float64 {
+	return g.cells[mod(coords.X, g.width)*g.height+mod(coords.Y, g.height)]
+}
+
+// Coords past the edges wrap around, and values are clamped to [0, 1]
+func (g *ContinuousGrid) Set(coords Point, value float64) {
+	g.cells[mod(coords.X, g.width)*g.height+mod(coords.Y, g.height)] = clampUnit(value)
+}
+
+func (g *ContinuousGrid) Clear() {
+	for i := range g.cells {
+		g.cells[i] = 0
+	}
+}
+
+// Returns the sum of every cell
+func (g *ContinuousGrid) Mass() float64 {
+	var mass float64
+	for _, value := range g.cells {
+		mass += value
+	}
+
+	return mass
+}
+
+// Calls callback with every cell that is not 0, column by column
+func (g *ContinuousGrid) ForEach(callback func(coords Point, value float64)) {
+	for i, value := range g.cells {
+		if value != 0 {
+			callback(Point{X: i / g.height, Y: i % g.height}, value)
+		}
+	}
+}
+
+// Updates every cell at once from the potentials of the rule's kernels
+func (g *ContinuousGrid) Step(rule ContinuousRule) {
+	kernels := rule.Kernels()
+
+	fields := make([][]float64, len(kernels))
+	for i, kernel := range kernels {
+		fields[i] = g.Convolve(kernel)
+	}
+
+	potentials := make([]float64, len(kernels))
+	for i, value := range g.cells {
+		for k, field := range fields {
+			potentials[k] = field[i]
+		}
+
+		g.cells[i] = clampUnit(rule.Update(value, potentials))
+	}
+}
+
+// Returns the potential of every cell, which is the weighted sum of the cells around it
+// Big kernels are convolved with FFTs, small ones directly
+func (g *ContinuousGrid) Convolve(kernel *ContinuousKernel) []float64 {
+	if kernel.radius >= FFT_MIN_KERNEL_RADIUS {
+		return g.convolveFFT(kernel)
+	}
+
+	return g.convolveDirect(kernel)
+}
+
+func (g *ContinuousGrid) convolveDirect(kernel *ContinuousKernel) []float64 {
+	field := make([]float64, len(g.cells))
+
+	for x := 0; x < g.width; x++ {
+		for y := 0; y < g.height; y++ {
+			var sum float64
+			for _, tap := range kernel.taps {
+				sum += tap.weight * g.cells[mod(x+tap.offset.X, g.width)*g.height+mod(y+tap.offset.Y, g.height)]
+			}
+
+			field[x*g.height+y] = sum
+		}
+	}
+
+	return field
+}
+
+func (g *ContinuousGrid) convolveFFT(kernel *ContinuousKernel) []float64 {
+	spectrum, ok := g.spectra[kernel]
+	if !ok {
+		// Weights go where the cells they read from would be, mirrored, so the product is a correlation like convolveDirect
+		spectrum = make([]complex128, len(g.cells))
+		for _, tap := range kernel.taps {
+			spectrum[mod(-tap.offset.X, g.width)*g.height+mod(-tap.offset.Y, g.height)] += complex(tap.weight, 0)
+		}
+
+		fft2D(spectrum, g.width, g.height, g.planX, g.planY, false)
+		g.spectra[kernel] = spectrum
+	}
+
+	data := make([]complex128, len(g.cells))
+	for i, value := range g.cells {
+		data[i] = complex(value, 0)
+	}
+
+	fft2D(data, g.width, g.height, g.planX, g.planY, false)
+	for i := range data {
+		data[i] *= spectrum[i]
+	}
+	fft2D(data, g.width, g.height, g.planX, g.planY, true)
+
+	field := make([]float64, len(g.cells))
+	for i, value := range data {
+		field[i] = real(value)
+	}
+
+	return field
+}
+
+//* -------------------------
+//* CONTINUOUS KERNEL
+//* -------------------------
+// Weights around a cell that sum to 1, so potentials are between 0 and 1 as well
+type ContinuousKernel struct {
+	radius int
+	taps   []weightTap // Only the weights that are not 0
+}
+
+// Weighs every cell within radius by shell, given its distance from the center divided by radius (0 to 1)
+func NewContinuousKernel(radius int, shell func(distance float64) float64) *ContinuousKernel {
+	kernel := &ContinuousKernel{radius: radius}
+
+	var total float64
+	for x := -radius; x <= radius; x++ {
+		for y := -radius; y <= radius; y++ {
+			weight := shell(math.Hypot(float64(x), float64(y)) / float64(radius))
+			if weight == 0 {
+				continue
+			}
+
+			kernel.taps = append(kernel.taps, weightTap{offset: Point{X: x, Y: y}, weight: weight})
+			total += weight
+		}
+	}
+
+	for i := range kernel.taps {
+		kernel.taps[i].weight /= total
+	}
+
+	return kernel
+}
+
+// Lenia kernel of concentric rings, each a smooth bump peaking at its height
+// A single peak of 1 is the classic ring, e.g. for Orbium
+func RingKernel(radius int, peaks []float64) *ContinuousKernel {
+	return NewContinuousKernel(radius, func(distance float64) float64 {
+		if distance >= 1 || len(peaks) == 0 {
+			return 0
+		}
+
+		rings := distance * float64(len(peaks))
+		ring := int(rings)
+
+		return peaks[ring] * bump(rings-float64(ring))
+	})
+}
+
+// Disk (inner 0) or annulus from inner to outer cells, with edges antialiased over one cell
+func AnnulusKernel(inner, outer float64) *ContinuousKernel {
+	radius := int(math.Ceil(outer + 0.5))
+
+	return NewContinuousKernel(radius, func(distance float64) float64 {
+		cells := distance * float64(radius)
+		weight := clampUnit(outer + 0.5 - cells)
+		if inner > 0 {
+			weight -= clampUnit(inner + 0.5 - cells)
+		}
+
+		return weight
+	})
+}
+
+func (k *ContinuousKernel) Radius() int {
+	return k.radius
+}
+
+// Smooth bump that is 0 at 0 and 1, and 1 halfway
+func bump(x float64) float64 {
+	if x <= 0 || x >= 1 {
+		return 0
+	}
+
+	return math.Exp(4 - 1/(x*(1-x)))
+}
+
+func clampUnit(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
@@ -0,0 +1,3 @@
+// Package automaton is the headless simulation core: grids, windows, dots and the rules that convolve them.
+// It has no graphics dependencies, so rules can run in tests, on servers and in CI.
+package automaton
@@ -0,0 +1,243 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//* -------------------------
+//* ONE DIMENSIONAL RULE
+//* -------------------------
+// Rule for a single row of cells, where every cell looks at radius cells to each side
+// Elementary rules (radius 1) are numbered 0 to 255 by Wolfram's code, where bit n is the next value for the neighbourhood n,
+// read as a binary number with the left cell as the highest bit
+// Totalistic rules only look at the number of live cells, so bit n of the code is the next value when n cells are alive
+type OneDimensionalRule struct {
+	radius     int
+	totalistic bool
+	code       uint64
+	table      []bool // Indexed by neighbourhood, or by sum for totalistic rules
+}
+
+func NewElementaryRule(code int) (*OneDimensionalRule, error) {
+	if !between(code, 0, 255) {
+		return nil, fmt.Errorf("elementary rule %d is not between 0 and 255", code)
+	}
+
+	return newOneDimensionalRule(uint64(code), 1, false, 8), nil
+}
+
+// Radius 1 totalistic rules are elementary rules too, but numbered differently
+func NewTotalisticRule(code uint64, radius int) (*OneDimensionalRule, error) {
+	if !between(radius, 1, 31) {
+		return nil, fmt.Errorf("radius %d is not between 1 and 31", radius)
+	}
+
+	sums := radius*2 + 2 // 0 to 2*radius+1 live cells
+	if sums < 64 && code >= 1<<sums {
+		return nil, fmt.Errorf("totalistic code %d is too big for radius %d, the highest is %d", code, radius, uint64(1)<<sums-1)
+	}
+
+	return newOneDimensionalRule(code, radius, true, sums), nil
+}
+
+func newOneDimensionalRule(code uint64, radius int, totalistic bool, entries int) *OneDimensionalRule {
+	rule := &OneDimensionalRule{radius: radius, totalistic: totalistic, code: code, table: make([]bool, entries)}
+	for i := range rule.table {
+		rule.table[i] = code&(1<<i) != 0
+	}
+
+	return rule
+}
+
+func (r *OneDimensionalRule) Radius() int {
+	return r.radius
+}
+
+// Returns the next value of the cell in the middle of neighbourhood, which has radius cells on each side
+func (r *OneDimensionalRule) Next(neighbourhood []bool) bool {
+	var index int
+
+	for _, alive := range neighbourhood {
+		if r.totalistic {
+			if alive {
+				index++
+			}
+			continue
+		}
+
+		index <<= 1
+		if alive {
+			index |= 1
+		}
+	}
+
+	return r.table[index]
+}
+
+// Returns the rule as ParseOneDimensionalRule reads it
+func (r *OneDimensionalRule) String() string {
+	if r.totalistic {
+		return fmt.Sprintf("T%d,R%d", r.code, r.radius)
+	}
+
+	return fmt.Sprintf("W%d", r.code)
+}
+
+// Parses an elementary rule like 30 or W30, or a totalistic rule like T52,R2 (code 52, radius 2)
+func ParseOneDimensionalRule(spec string) (*OneDimensionalRule, error) {
+	spec = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(spec), " ", ""))
+
+	if !strings.HasPrefix(spec, "T") {
+		code, err := strconv.Atoi(strings.TrimPrefix(spec, "W"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: expected a Wolfram code like 30 or W30", spec)
+		}
+
+		rule, err := NewElementaryRule(code)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", spec, err)
+		}
+
+		return rule, nil
+	}
+
+	var code uint64
+	radius := 1
+	var err error
+
+	for _, part := range strings.Split(spec, ",") {
+		if part == "" {
+			return nil, fmt.Errorf("invalid rule %q: empty part", spec)
+		}
+
+		switch part[0] {
+		case 'T':
+			code, err = strconv.ParseUint(part[1:], 10, 64)
+		case 'R':
+			radius, err = strconv.Atoi(part[1:])
+		default:
+			err = fmt.Errorf("unknown part %q", part)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", spec, err)
+		}
+	}
+
+	rule, err := NewTotalisticRule(code, radius)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %v", spec, err)
+	}
+
+	return rule, nil
+}
+
+//* -------------------------
+//* SPACETIME
+//* -------------------------
+// Runs a one dimensional rule on a grid, writing every generation as the row below the one before
+// Once the bottom row is written, everything scrolls up a row to make room, so the grid shows the latest history
+// The grid's boundary decides what lies past the ends of a row
+type Spacetime struct {
+	grid *Grid
+	rule *OneDimensionalRule
+	row  int // The row holding the latest generation
+}
+
+func NewSpacetime(grid *Grid, rule *OneDimensionalRule) (*Spacetime, error) {
+	if grid.Unbounded() {
+		return nil, errors.New("a spacetime diagram needs a bounded grid, so it knows where to scroll")
+	}
+
+	return &Spacetime{grid: grid, rule: rule}, nil
+}
+
+func (s *Spacetime) Grid() *Grid {
+	return s.grid
+}
+
+func (s *Spacetime) Rule() *OneDimensionalRule {
+	return s.rule
+}
+
+// Returns the row holding the latest generation
+func (s *Spacetime) Row() int {
+	return s.row
+}
+
+// Clears the grid and writes the seed as the top row, centered when it is narrower than the grid
+func (s *Spacetime) Seed(seed []bool) {
+	s.grid.Clear()
+	s.row = 0
+
+	offset := (s.grid.Width() - len(seed)) / 2
+	for i, alive := range seed {
+		if x := offset + i; alive && between(x, 0, s.grid.Width()-1) {
+			NewDot(Point{X: x, Y: 0}, s.grid)
+		}
+	}
+}
+
+// Writes the next generation below the latest one
+func (s *Spacetime) Step() {
+	width := s.grid.Width()
+	next := make([]bool, width)
+	neighbourhood := make([]bool, s.rule.radius*2+1)
+
+	for x := range next {
+		for i := range neighbourhood {
+			neighbourhood[i] = s.grid.Lookup(Point{X: x - s.rule.radius + i, Y: s.row}) != nil
+		}
+
+		next[x] = s.rule.Next(neighbourhood)
+	}
+
+	if s.row == s.grid.Height()-1 {
+		s.scroll()
+	} else {
+		s.row++
+	}
+
+	for x, alive := range next {
+		if alive {
+			NewDot(Point{X: x, Y: s.row}, s.grid)
+		}
+	}
+}
+
+// Moves every row up by one, dropping the top row and leaving the bottom row empty
+func (s *Spacetime) scroll() {
+	var dots []*Dot
+	s.grid.ForEach(func(dot *Dot) {
+		if dot.Position().Y > 0 {
+			dots = append(dots, dot)
+		}
+	})
+
+	s.grid.Clear()
+
+	for _, dot := range dots {
+		position := dot.Position()
+		s.grid.Set(Point{X: position.X, Y: position.Y - 1}, dot)
+	}
+}
+
+// Parses a seed row written as 0s and 1s, like 00100
+func ParseSeedRow(row string) ([]bool, error) {
+	seed := make([]bool, len(row))
+
+	for i, char := range row {
+		switch char {
+		case '0':
+		case '1':
+			seed[i] = true
+		default:
+			return nil, fmt.Errorf("invalid seed row %q: %q is not 0 or 1", row, char)
+		}
+	}
+
+	return seed, nil
+}
@@ -0,0 +1,144 @@
+package automaton
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+//* -------------------------
+//* FFT PLAN
+//* -------------------------
+// Discrete Fourier transform of a fixed length
+// Powers of two use a radix-2 FFT directly, other lengths go through Bluestein's algorithm,
+// which turns them into a convolution of the next power of two that fits
+type fftPlan struct {
+	n        int
+	m        int          // Padded power of two length for Bluestein, 0 for powers of two
+	chirp    []complex128 // exp(-i*pi*k^2/n)
+	spectrum []complex128 // Transform of the conjugate chirp, padded to m
+}
+
+func newFFTPlan(n int) *fftPlan {
+	plan := &fftPlan{n: n}
+	if n&(n-1) == 0 {
+		return plan
+	}
+
+	plan.m = 1 << bits.Len(uint(2*n-1))
+	plan.chirp = make([]complex128, n)
+	plan.spectrum = make([]complex128, plan.m)
+
+	for k := 0; k < n; k++ {
+		// k^2 mod 2n keeps the angle small, since exp is periodic
+		angle := math.Pi * float64((k*k)%(2*n)) / float64(n)
+		plan.chirp[k] = cmplx.Rect(1, -angle)
+
+		plan.spectrum[k] = cmplx.Conj(plan.chirp[k])
+		if k > 0 {
+			plan.spectrum[plan.m-k] = plan.spectrum[k]
+		}
+	}
+	radix2(plan.spectrum, false)
+
+	return plan
+}
+
+// Transforms data in place, the inverse includes the division by n
+func (p *fftPlan) transform(data []complex128, inverse bool) {
+	if inverse {
+		// The inverse is the forward transform of the conjugate, conjugated again
+		for i := range data {
+			data[i] = cmplx.Conj(data[i])
+		}
+	}
+
+	if p.m == 0 {
+		radix2(data, false)
+	} else {
+		p.bluestein(data)
+	}
+
+	if inverse {
+		scale := complex(1/float64(p.n), 0)
+		for i := range data {
+			data[i] = cmplx.Conj(data[i]) * scale
+		}
+	}
+}
+
+func (p *fftPlan) bluestein(data []complex128) {
+	padded := make([]complex128, p.m)
+	for k, value := range data {
+		padded[k] = value * p.chirp[k]
+	}
+
+	radix2(padded, false)
+	for i := range padded {
+		padded[i] *= p.spectrum[i]
+	}
+	radix2(padded, true)
+
+	for k := range data {
+		data[k] = padded[k] * p.chirp[k] / complex(float64(p.m), 0)
+	}
+}
+
+// In place iterative FFT, len(data) must be a power of two
+// The inverse is not divided by the length
+func radix2(data []complex128, inverse bool) {
+	n := len(data)
+	if n < 2 {
+		return
+	}
+
+	// Bit reversed order
+	shift := bits.UintSize - bits.Len(uint(n-1))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse(uint(i)) >> shift)
+		if i < j {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(size))
+
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+
+			for k := 0; k < size/2; k++ {
+				even, odd := data[start+k], data[start+k+size/2]*w
+				data[start+k] = even + odd
+				data[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// Transforms width*height values indexed [x*height+y] in place, along both axes
+func fft2D(data []complex128, width, height int, planX, planY *fftPlan, inverse bool) {
+	// Columns are contiguous
+	for x := 0; x < width; x++ {
+		planY.transform(data[x*height:(x+1)*height], inverse)
+	}
+
+	row := make([]complex128, width)
+	for y := 0; y < height; y++ {
+		for x := range row {
+			row[x] = data[x*height+y]
+		}
+
+		planX.transform(row, inverse)
+
+		for x, value := range row {
+			data[x*height+y] = value
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package automaton
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// The last dying state fades towards this
+var dyingFill = mustParseHexColor("#364fc7")
+
+//* -------------------------
+//* GENERATIONS RULE
+//* -------------------------
+// Multi-state rule, where a live cell that does not survive goes through refractory states before it is dead
+// Only live cells (state 1) count as neighbours, and dying cells can not be born again until they have vanished
+// Written like B2/S/C3, where C is the number of states including dead, so C2 is a plain Life-like rule
+type GenerationsRule struct {
+	Kernel
+	birth, survival [256]bool // Indexed by ring index, so counts can have Hensel letters too
+	states          int
+	fills           []color.Color // Indexed by state, from alive to the last dying state
+}
+
+// birth and survival are written like "2" or "2-a3", states includes the dead state
+func NewGenerationsRule(birth, survival string, states int) (*GenerationsRule, error) {
+	if states < 2 {
+		return nil, fmt.Errorf("a Generations rule needs at least 2 states, got %d", states)
+	}
+
+	rule := &GenerationsRule{Kernel: Kernel{size: 3}, states: states}
+
+	var err error
+	if rule.birth, err = parseHensel(birth); err != nil {
+		return nil, fmt.Errorf("invalid birth %q: %v", birth, err)
+	}
+	if rule.survival, err = parseHensel(survival); err != nil {
+		return nil, fmt.Errorf("invalid survival %q: %v", survival, err)
+	}
+
+	// State 0 is dead and never drawn
+	rule.fills = append([]color.Color{nil}, Gradient(dotFill, dyingFill, states-1)...)
+
+	return rule, nil
+}
+
+func (r *GenerationsRule) ApplyKernel(win *Window) *Dot {
+	center := win.Center()
+
+	if center == nil {
+		if r.birth[r.firingIndex(win)] {
+			return NewDot(win.GridCoords(), nil)
+		}
+
+		return nil
+	}
+
+	if center.State() == 1 && r.survival[r.firingIndex(win)] {
+		return center
+	}
+
+	// Dying cells always move on to the next state
+	next := center.State() + 1
+	if next >= r.states {
+		return nil
+	}
+
+	return NewStateDot(win.GridCoords(), next, r.fills[next], nil)
+}
+
+func (r *GenerationsRule) Size() int {
+	return r.size
+}
+
+func (r *GenerationsRule) States() int {
+	return r.states
+}
+
+// Returns the fill of every state, index 0 (dead) is nil
+func (r *GenerationsRule) Fills() []color.Color {
+	return r.fills
+}
+
+func (r *GenerationsRule) Palette() Palette {
+	return dyingPalette(r.fills)
+}
+
+// Returns the rule in B/S/C notation, e.g. B2/S/C3
+func (r *GenerationsRule) String() string {
+	return fmt.Sprintf("B%s/S%s/C%d", formatHensel(r.birth), formatHensel(r.survival), r.states)
+}
+
+// Like ringIndex, but dying neighbours count as dead
+func (r *GenerationsRule) firingIndex(win *Window) int {
+	var ring int
+
+	for position, offset := range ringOffsets {
+		if dot := win.Get(offset); dot != nil && dot.State() == 1 {
+			ring |= 1 << position
+		}
+	}
+
+	return ring
+}
+
+// Takes the number of states out of a rule, e.g. B2/S/C3 or 345/2/4 (S/B/C), leaving the birth and survival part
+// Rules without a number of states have 2
+func splitStates(rule string) (string, int, error) {
+	parts := strings.Split(strings.ReplaceAll(rule, " ", ""), "/")
+
+	// 345/2/4 has only digits
+	if len(parts) == 3 && isDigits(parts[0]) && isDigits(parts[1]) && isDigits(parts[2]) && parts[2] != "" {
+		states, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid rule %q: %v", rule, err)
+		}
+
+		return parts[0] + "/" + parts[1], states, nil
+	}
+
+	// A section can never start with a Hensel letter, so a leading C is always the number of states
+	for i, part := range parts {
+		if len(part) < 2 || (part[0] != 'C' && part[0] != 'c') || !isDigits(part[1:]) {
+			continue
+		}
+
+		states, err := strconv.Atoi(part[1:])
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid rule %q: %v", rule, err)
+		}
+
+		rest := append(append([]string{}, parts[:i]...), parts[i+1:]...)
+
+		return strings.Join(rest, "/"), states, nil
+	}
+
+	return rule, 2, nil
+}
+
+func isDigits(str string) bool {
+	for _, char := range str {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+
+	return true
+}
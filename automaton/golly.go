@@ -0,0 +1,245 @@
+package automaton
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+const (
+	MAX_GOLLY_STATES = 256
+)
+
+// The last state fades towards this, unless the file has its own @COLORS
+var gollyFill = mustParseHexColor("#be4bdb")
+
+// Transition tables and trees both look up the next state of a cell from the states of the cells around it
+type gollyTransitions interface {
+	next(cells []int) (int, bool) // cells are in the order of the rule's offsets, false when nothing matches
+}
+
+//* -------------------------
+//* GOLLY RULE
+//* -------------------------
+// Rule loaded from one of Golly's rule files: a .rule file with a @TABLE or @TREE section (and optionally @COLORS),
+// or a bare .table or .tree file
+// Cells that no transition matches keep their state
+type GollyRule struct {
+	Kernel
+	name         string
+	states       int
+	neighborhood string  // moore, vonneumann or hexagonal
+	offsets      []Point // Cells the transitions read, relative to the center, in the order they read them
+	transitions  gollyTransitions
+	fills        []color.Color // Indexed by state, 0 (empty) is nil
+}
+
+// Parses the text of a Golly rule file, name is used when the file has no @RULE line (e.g. for a bare .table)
+func ParseGollyRule(name, text string) (*GollyRule, error) {
+	sections := splitGollySections(text)
+	if ruleName := strings.TrimSpace(sections["@RULE"]); ruleName != "" {
+		name = ruleName
+	}
+
+	var rule *GollyRule
+	var err error
+
+	table, hasTable := sections["@TABLE"]
+	tree, hasTree := sections["@TREE"]
+
+	switch {
+	case hasTable:
+		rule, err = parseRuleTable(table)
+	case hasTree:
+		rule, err = parseRuleTree(tree)
+	default:
+		err = errors.New("expected a @TABLE or @TREE section")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid Golly rule %q: %v", name, err)
+	}
+
+	rule.name = name
+	rule.fills = append([]color.Color{nil}, Gradient(dotFill, gollyFill, rule.states-1)...)
+
+	if colors, ok := sections["@COLORS"]; ok {
+		if err := rule.parseColors(colors); err != nil {
+			return nil, fmt.Errorf("invalid Golly rule %q: %v", name, err)
+		}
+	}
+
+	return rule, nil
+}
+
+func newGollyRule(states int, neighborhood string, offsets []Point, transitions gollyTransitions) *GollyRule {
+	return &GollyRule{
+		Kernel:       Kernel{size: 3},
+		states:       states,
+		neighborhood: neighborhood,
+		offsets:      offsets,
+		transitions:  transitions,
+	}
+}
+
+// Returns the text of every section by its header, e.g. "@TABLE", and the name after @RULE as its text
+// Files without any section are a bare table or tree, depending on how their settings are written
+func splitGollySections(text string) map[string]string {
+	sections := make(map[string]string)
+	var current string
+	var body strings.Builder
+
+	flush := func() {
+		if current != "" {
+			sections[current] += body.String()
+		}
+		body.Reset()
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "@") {
+			flush()
+
+			fields := strings.Fields(line)
+			current = strings.ToUpper(fields[0])
+			if current == "@RULE" && len(fields) > 1 {
+				body.WriteString(fields[1])
+			}
+			continue
+		}
+
+		if current == "" {
+			// Before any section, so this is a bare file
+			if strings.Contains(text, "num_states") {
+				current = "@TREE"
+			} else {
+				current = "@TABLE"
+			}
+		}
+
+		if current != "@RULE" {
+			body.WriteString(line + "\n")
+		}
+	}
+
+	flush()
+
+	return sections
+}
+
+// Reads lines like "1 255 0 0" (state, red, green, blue), or "255 0 0 0 0 255" for a gradient over every live state
+func (r *GollyRule) parseColors(text string) error {
+	for _, line := range gollyLines(text) {
+		var numbers []int
+		for _, field := range strings.Fields(line) {
+			number, err := strconv.Atoi(field)
+			if err != nil {
+				return fmt.Errorf("invalid colour line %q", line)
+			}
+
+			numbers = append(numbers, number)
+		}
+
+		switch len(numbers) {
+		case 4:
+			if between(numbers[0], 1, r.states-1) {
+				r.fills[numbers[0]] = color.RGBA{R: uint8(numbers[1]), G: uint8(numbers[2]), B: uint8(numbers[3]), A: 0xff}
+			}
+		case 6:
+			from := color.RGBA{R: uint8(numbers[0]), G: uint8(numbers[1]), B: uint8(numbers[2]), A: 0xff}
+			to := color.RGBA{R: uint8(numbers[3]), G: uint8(numbers[4]), B: uint8(numbers[5]), A: 0xff}
+			r.fills = append([]color.Color{nil}, Gradient(from, to, r.states-1)...)
+		default:
+			return fmt.Errorf("invalid colour line %q, expected a state and 3 numbers, or 6 numbers for a gradient", line)
+		}
+	}
+
+	return nil
+}
+
+// Returns the lines of a section without comments and blank lines
+func gollyLines(text string) []string {
+	var lines []string
+
+	for _, line := range strings.Split(text, "\n") {
+		if comment := strings.IndexByte(line, '#'); comment >= 0 {
+			line = line[:comment]
+		}
+
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func (r *GollyRule) ApplyKernel(win *Window) *Dot {
+	var cells [9]int
+	for i, offset := range r.offsets {
+		if dot := win.Get(Point{X: 1 + offset.X, Y: 1 + offset.Y}); dot != nil {
+			cells[i] = dot.State()
+		}
+	}
+
+	return r.apply(cells[:len(r.offsets)], win.Center(), win.GridCoords())
+}
+
+// Reads the cells straight from the grid, which saves building a window for every cell
+func (r *GollyRule) ApplyCell(grid *Grid, coords Point) *Dot {
+	var cells [9]int
+	for i, offset := range r.offsets {
+		if dot := grid.Lookup(Point{X: coords.X + offset.X, Y: coords.Y + offset.Y}); dot != nil {
+			cells[i] = dot.State()
+		}
+	}
+
+	return r.apply(cells[:len(r.offsets)], grid.Lookup(coords), coords)
+}
+
+func (r *GollyRule) apply(cells []int, center *Dot, coords Point) *Dot {
+	state, ok := r.transitions.next(cells)
+	if !ok {
+		return center
+	}
+
+	if state == 0 {
+		return nil
+	}
+	if center != nil && center.State() == state {
+		return center
+	}
+
+	return NewStateDot(coords, state, r.fills[state], nil)
+}
+
+func (r *GollyRule) Size() int {
+	return r.size
+}
+
+func (r *GollyRule) States() int {
+	return r.states
+}
+
+func (r *GollyRule) Palette() Palette {
+	palette := make(Palette, 0, r.states-1)
+	for state := 1; state < r.states; state++ {
+		palette = append(palette, PaletteEntry{State: state, Name: fmt.Sprintf("state%d", state), Fill: r.fills[state]})
+	}
+
+	return palette
+}
+
+func (r *GollyRule) Hexagonal() bool {
+	return r.neighborhood == "hexagonal"
+}
+
+func (r *GollyRule) String() string {
+	return r.name
+}
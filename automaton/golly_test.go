@@ -0,0 +1,274 @@
+package automaton
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const lifePermuteTable = `@RULE LifePermute
+# Conway's Life, with every arrangement of the neighbours left to permute
+@TABLE
+n_states:2
+neighborhood:Moore
+symmetries:permute
+var a={0,1}
+var b={0,1}
+var c={0,1}
+var d={0,1}
+0,1,1,1,0,0,0,0,0,1
+1,0,0,0,0,0,0,0,0,0
+1,1,0,0,0,0,0,0,0,0
+1,1,1,1,1,a,b,c,d,0
+@COLORS
+1 255 255 0
+`
+
+// Conway's Life as Golly's RuleTreeGen writes it, reading NW, NE, SW, SE, N, W, E, S and then the center
+const lifeTree = `num_states=2
+num_neighbors=8
+num_nodes=32
+1 0 0
+2 0 0
+1 0 1
+2 0 2
+3 1 3
+1 1 1
+2 2 5
+3 3 6
+4 4 7
+2 5 0
+3 6 9
+4 7 10
+5 8 11
+3 9 1
+4 10 13
+5 11 14
+6 12 15
+3 1 1
+4 13 17
+5 14 18
+6 15 19
+7 16 20
+4 17 17
+5 18 22
+6 19 23
+7 20 24
+8 21 25
+5 22 22
+6 23 27
+7 24 28
+8 25 29
+9 26 30
+`
+
+func mustParseGollyRule(t *testing.T, name, text string) *GollyRule {
+	t.Helper()
+
+	rule, err := ParseGollyRule(name, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rule
+}
+
+// Fails unless the rule compiles to the same lookup table as B3/S23
+func compareWithLife(t *testing.T, rule *GollyRule) {
+	t.Helper()
+
+	life, err := CompileLUT(mustParseRule(t, "B3/S23"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compiled, err := CompileLUT(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if compiled.String() != life.String() {
+		t.Fatalf("%v compiles to %v, expected B3/S23's %v", rule, compiled, life)
+	}
+}
+
+func TestGollyLifeTable(t *testing.T) {
+	rule := mustParseGollyRule(t, "ignored", lifePermuteTable)
+	if rule.String() != "LifePermute" {
+		t.Fatalf("name is %q, expected the one after @RULE", rule.String())
+	}
+
+	compareWithLife(t, rule)
+
+	if fill, _ := rule.Palette().Fill(1); fmt.Sprint(fill) != fmt.Sprint(mustParseHexColor("#ffff00")) {
+		t.Fatalf("state 1 is %v, expected the colour from @COLORS", fill)
+	}
+}
+
+func TestGollyLifeTree(t *testing.T) {
+	compareWithLife(t, mustParseGollyRule(t, "Life", lifeTree))
+	compareWithLife(t, mustParseGollyRule(t, "Life", "@RULE Life\n@TREE\n"+lifeTree))
+}
+
+// Golly files in the wild separate their entries with tabs as well as spaces
+func TestGollyTableWhitespace(t *testing.T) {
+	table := strings.NewReplacer(",", ",\t", "var ", "var\t", "{0,1}", "{ 0 ,\t1 }").Replace(lifePermuteTable)
+	compareWithLife(t, mustParseGollyRule(t, "Life", table))
+
+	spaced := "n_states:2\nsymmetries:permute\n0 1 1 1 0 0 0 0 0 1\n1\t0\t0\t0\t0\t0\t0\t0\t0\t0\n1100000000\n"
+	rule := mustParseGollyRule(t, "Life", spaced)
+
+	compiled, err := CompileLUT(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Birth on 3, death on 0 and 1, the rest keeps its state
+	if births := countBirths(compiled); births != 56 {
+		t.Fatalf("%d neighbourhoods give birth, expected the 56 with 3 live neighbours", births)
+	}
+}
+
+// Returns how many neighbourhoods with a dead center give birth
+func countBirths(lut *LUT) int {
+	var births int
+	for neighbourhood, alive := range lut.table {
+		if neighbourhood&(1<<4) == 0 && alive {
+			births++
+		}
+	}
+
+	return births
+}
+
+func TestGollyTableSymmetries(t *testing.T) {
+	for _, test := range []struct {
+		symmetries, transition string
+		births                 int
+	}{
+		// Only north alive, which the rotations turn into every orthogonal (and then also diagonal) neighbour
+		{"none", "0,1,0,0,0,0,0,0,0,1", 1},
+		{"rotate4", "0,1,0,0,0,0,0,0,0,1", 4},
+		{"rotate8", "0,1,0,0,0,0,0,0,0,1", 8},
+		{"permute", "0,1,0,0,0,0,0,0,0,1", 8},
+		// North and north east alive, reflecting adds north and north west
+		{"reflect", "0,1,1,0,0,0,0,0,0,1", 2},
+		{"rotate4", "0,1,1,0,0,0,0,0,0,1", 4},
+		{"rotate4reflect", "0,1,1,0,0,0,0,0,0,1", 8},
+		{"rotate8", "0,1,1,0,0,0,0,0,0,1", 8},
+		{"rotate8reflect", "0,1,1,0,0,0,0,0,0,1", 8},
+		// North and south alive, which is the same after half a turn
+		{"rotate4", "0,1,0,0,0,1,0,0,0,1", 2},
+		{"permute", "0,1,0,0,0,1,0,0,0,1", 28},
+	} {
+		t.Run(test.symmetries+"/"+test.transition, func(t *testing.T) {
+			rule := mustParseGollyRule(t, "test", fmt.Sprintf("n_states:2\nneighborhood:Moore\nsymmetries:%s\n%s\n", test.symmetries, test.transition))
+
+			compiled, err := CompileLUT(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if births := countBirths(compiled); births != test.births {
+				t.Fatalf("%d neighbourhoods give birth, expected %d", births, test.births)
+			}
+		})
+	}
+}
+
+func TestGollyTableBoundVariables(t *testing.T) {
+	// Cells copy the state of their northern neighbour, as long as it matches the north eastern one
+	rule := mustParseGollyRule(t, "copy", "n_states:3\nvar a={1,2}\n0,a,a,0,0,0,0,0,0,a\n")
+
+	grid := NewGrid(8, 8)
+	for _, cell := range []struct {
+		x, y, state int
+	}{{2, 2, 1}, {3, 2, 1}, {5, 5, 2}, {6, 5, 2}, {2, 6, 1}, {3, 6, 2}} {
+		NewStateDot(Point{X: cell.x, Y: cell.y}, cell.state, nil, grid)
+	}
+
+	grid.Convolve(rule)
+
+	for coords, want := range map[Point]int{
+		{X: 2, Y: 3}: 1, // Below a pair of 1s
+		{X: 5, Y: 6}: 2, // Below a pair of 2s
+		{X: 2, Y: 7}: 0, // Below a 1 next to a 2
+		{X: 3, Y: 3}: 0, // Nothing north east
+	} {
+		var got int
+		if dot := grid.Lookup(coords); dot != nil {
+			got = dot.State()
+		}
+
+		if got != want {
+			t.Errorf("cell %v is in state %d, expected %d", coords, got, want)
+		}
+	}
+}
+
+func TestGollyWireworldTable(t *testing.T) {
+	// Wireworld with the states of NewWireworld: 1 conductor, 2 head and 3 tail
+	table := `n_states:4
+neighborhood:Moore
+symmetries:rotate8
+var a={0,1,2,3}
+var b={0,1,2,3}
+var c={0,1,2,3}
+var d={0,1,2,3}
+var e={0,1,2,3}
+var f={0,1,2,3}
+var g={0,1,2,3}
+var h={0,1,2,3}
+var i={0,1,3}
+var j={0,1,3}
+var k={0,1,3}
+var l={0,1,3}
+var m={0,1,3}
+var n={0,1,3}
+2,a,b,c,d,e,f,g,h,3
+3,a,b,c,d,e,f,g,h,1
+1,2,i,j,k,l,m,n,a,2
+1,2,2,i,j,k,l,m,n,2
+1,2,i,2,j,k,l,m,n,2
+1,2,i,j,2,k,l,m,n,2
+1,2,i,j,k,2,l,m,n,2
+`
+	rule := mustParseGollyRule(t, "Wireworld", table)
+
+	want, got := NewGrid(20, 20), NewGrid(20, 20)
+	for x := 2; x < 18; x++ {
+		for _, grid := range []*Grid{want, got} {
+			NewDot(Point{X: x, Y: 10}, grid)
+			NewDot(Point{X: 10, Y: x}, grid)
+		}
+	}
+	for _, grid := range []*Grid{want, got} {
+		NewStateDot(Point{X: 3, Y: 10}, WIREWORLD_HEAD, nil, grid)
+		NewStateDot(Point{X: 2, Y: 10}, WIREWORLD_TAIL, nil, grid)
+	}
+
+	for generation := 1; generation <= 20; generation++ {
+		want.Convolve(NewWireworld())
+		got.Convolve(rule)
+
+		compareGrids(t, generation, want, got)
+	}
+}
+
+func TestGollyRuleErrors(t *testing.T) {
+	for _, text := range []string{
+		"@RULE nothing\n@COLORS\n1 255 0 0\n",
+		"0,1,1,1,0,0,0,0,0,1\n",
+		"n_states:2\nsymmetries:rotate3\n0,1,0,0,0,0,0,0,0,1\n",
+		"n_states:2\nneighborhood:oneDimensional\n",
+		"n_states:2\n0,0,0,1\n",
+		"n_states:2\n0,0,0,0,0,0,0,0,0,2\n",
+		"n_states:2\nvar a={0,1}\n0,0,0,0,0,0,0,0,0,a\n",
+		"num_states=2\nnum_neighbors=8\nnum_nodes=1\n1 0 1\n",
+		"num_states=2\nnum_neighbors=6\nnum_nodes=1\n1 0 1\n",
+	} {
+		if _, err := ParseGollyRule("bad", text); err == nil {
+			t.Errorf("expected an error for %q", text)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	MAX_HASHLIFE_NODES         = 1 << 22                // Unused nodes are thrown away when there are more than this many
+	MAX_HASHLIFE_LEVEL         = 62                     // The root spans 2^level cells, past this its coords overflow an int
+	MAX_HASHLIFE_STEP_EXPONENT = MAX_HASHLIFE_LEVEL - 3 // Steps need the root at least 3 levels above the exponent
+)
+
+//* -------------------------
+//* HASHLIFE
+//* -------------------------
+// Unbounded universe for binary 3x3 rules, stored as a memoized quadtree
+// Identical areas share a single node, and the future of every node is only ever computed once,
+// so repetitive patterns can be advanced 2^stepExponent generations at a time
+type HashLife struct {
+	rule         [LUT_SIZE]bool
+	nodes        map[quad]*node // Every node in the universe, so identical quadrants become the same pointer
+	empty        []*node        // Empty node for every level
+	root         *node          // Spans -2^(level-1) to 2^(level-1)-1 on both axes
+	stepExponent uint
+	generation   *big.Int
+}
+
+// The four quadrants of a node, which identify it
+type quad struct {
+	nw, ne, sw, se *node
+}
+
+type node struct {
+	quad
+	level      uint // Spans 2^level cells on each side, level 0 is a single cell
+	alive      bool // Only used by level 0
+	population uint64
+	result     *node // Center of this node, stepExponent generations into the future
+}
+
+var (
+	deadLeaf  = &node{}
+	aliveLeaf = &node{alive: true, population: 1}
+)
+
+// The rule is compiled into a lookup table first, so any deterministic binary rule with a Size() of 3 works,
+// as long as empty neighbourhoods stay empty (no B0)
+func NewHashLife(conv Convolver) (*HashLife, error) {
+	lut, err := CompileLUT(conv)
+	if err != nil {
+		return nil, err
+	}
+
+	// The quadtree treats everything past the root as empty forever
+	if lut.table[0] {
+		return nil, errors.New("rules that bring empty neighbourhoods to life (B0) would fill the whole universe, so HashLife can not run them")
+	}
+
+	h := &HashLife{rule: lut.table}
+	h.Clear()
+
+	return h, nil
+}
+
+func (h *HashLife) Clear() {
+	h.nodes = make(map[quad]*node)
+	h.empty = []*node{deadLeaf}
+	h.root = h.emptyNode(3)
+	h.generation = new(big.Int)
+}
+
+// Returns the number of generations that have been stepped through
+func (h *HashLife) Generation() *big.Int {
+	return new(big.Int).Set(h.generation)
+}
+
+func (h *HashLife) Population() uint64 {
+	return h.root.population
+}
+
+func (h *HashLife) StepExponent() uint {
+	return h.stepExponent
+}
+
+// Every Step will advance 2^exponent generations, exponents above MAX_HASHLIFE_STEP_EXPONENT are lowered to it
+func (h *HashLife) SetStepExponent(exponent uint) {
+	if exponent > MAX_HASHLIFE_STEP_EXPONENT {
+		exponent = MAX_HASHLIFE_STEP_EXPONENT
+	}
+
+	if exponent == h.stepExponent {
+		return
+	}
+
+	h.stepExponent = exponent
+
+	// Cached results are for the old step size
+	for _, n := range h.nodes {
+		n.result = nil
+	}
+}
+
+// Returns the number of generations a Step advances
+func (h *HashLife) StepSize() *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), h.stepExponent)
+}
+
+func (h *HashLife) Get(coords Point) bool {
+	half := h.half(h.root.level)
+	if !between(coords.X, -half, half-1) || !between(coords.Y, -half, half-1) {
+		return false
+	}
+
+	return h.root.get(coords.X+half, coords.Y+half)
+}
+
+// Cells more than 2^(MAX_HASHLIFE_LEVEL-1) away from the origin are ignored
+func (h *HashLife) Set(coords Point, alive bool) {
+	for !h.contains(coords) {
+		if h.root.level >= MAX_HASHLIFE_LEVEL {
+			return
+		}
+
+		h.root = h.expand(h.root)
+	}
+
+	half := h.half(h.root.level)
+	h.root = h.set(h.root, coords.X+half, coords.Y+half, alive)
+}
+
+// Loads every dot of the grid as a live cell, at the same coords
+func (h *HashLife) Load(grid *Grid) {
+	grid.ForEach(func(dot *Dot) {
+		h.Set(dot.Position(), true)
+	})
+}
+
+// Calls callback with the coords of every live cell within min and max (inclusive)
+// Empty quadrants are skipped entirely, so this is cheap for sparse universes
+func (h *HashLife) ForEachAlive(min, max Point, callback func(coords Point)) {
+	half := h.half(h.root.level)
+	h.forEachAlive(h.root, -half, -half, min, max, callback)
+}
+
+// Advances the universe 2^stepExponent generations
+// Fails without changing anything once the pattern needs a root past MAX_HASHLIFE_LEVEL
+func (h *HashLife) Step() error {
+	// The root needs enough empty space around the pattern, so nothing can reach past the part that is returned
+	root := h.root
+	for root.level < h.stepExponent+3 || !h.centered(root) {
+		if root.level >= MAX_HASHLIFE_LEVEL {
+			return fmt.Errorf("the pattern has reached the edge of the universe, which is 2^%d cells across", MAX_HASHLIFE_LEVEL)
+		}
+
+		root = h.expand(root)
+	}
+
+	// The result is the center half, so the root ends up at the same level again
+	h.root = h.result(h.expand(root))
+	h.generation.Add(h.generation, h.StepSize())
+
+	if len(h.nodes) > MAX_HASHLIFE_NODES {
+		h.collectGarbage()
+	}
+
+	return nil
+}
+
+// Forgets every node (and cached result) that is not part of the current universe
+func (h *HashLife) collectGarbage() {
+	h.nodes = make(map[quad]*node)
+	h.empty = []*node{deadLeaf}
+
+	rebuilt := make(map[*node]*node)
+
+	var rebuild func(n *node) *node
+	rebuild = func(n *node) *node {
+		if n.level == 0 {
+			return n
+		}
+		if r, ok := rebuilt[n]; ok {
+			return r
+		}
+
+		r := h.join(rebuild(n.nw), rebuild(n.ne), rebuild(n.sw), rebuild(n.se))
+		rebuilt[n] = r
+
+		return r
+	}
+
+	h.root = rebuild(h.root)
+}
+
+//* Node construction
+
+// Returns the canonical node with the given quadrants
+func (h *HashLife) join(nw, ne, sw, se *node) *node {
+	key := quad{nw, ne, sw, se}
+	if n, ok := h.nodes[key]; ok {
+		return n
+	}
+
+	n := &node{
+		quad:       key,
+		level:      nw.level + 1,
+		population: nw.population + ne.population + sw.population + se.population,
+	}
+	h.nodes[key] = n
+
+	return n
+}
+
+func (h *HashLife) emptyNode(level uint) *node {
+	for uint(len(h.empty)) <= level {
+		smaller := h.empty[len(h.empty)-1]
+		h.empty = append(h.empty, h.join(smaller, smaller, smaller, smaller))
+	}
+
+	return h.empty[level]
+}
+
+// Returns a node one level up, with n in the center
+func (h *HashLife) expand(n *node) *node {
+	border := h.emptyNode(n.level - 1)
+
+	return h.join(
+		h.join(border, border, border, n.nw),
+		h.join(border, border, n.ne, border),
+		h.join(border, n.sw, border, border),
+		h.join(n.se, border, border, border),
+	)
+}
+
+// Whether every live cell of n is within its center half
+func (h *HashLife) centered(n *node) bool {
+	return n.nw.se.population+n.ne.sw.population+n.sw.ne.population+n.se.nw.population == n.population
+}
+
+// Half the width of a node, which is the offset between world coords and node coords for the root
+func (h *HashLife) half(level uint) int {
+	return 1 << (level - 1)
+}
+
+func (h *HashLife) contains(coords Point) bool {
+	half := h.half(h.root.level)
+	return between(coords.X, -half, half-1) && between(coords.Y, -half, half-1)
+}
+
+// x and y are relative to the top left corner of n
+func (h *HashLife) set(n *node, x, y int, alive bool) *node {
+	if n.level == 0 {
+		if alive {
+			return aliveLeaf
+		}
+
+		return deadLeaf
+	}
+
+	half := h.half(n.level)
+	nw, ne, sw, se := n.nw, n.ne, n.sw, n.se
+
+	switch {
+	case x < half && y < half:
+		nw = h.set(nw, x, y, alive)
+	case y < half:
+		ne = h.set(ne, x-half, y, alive)
+	case x < half:
+		sw = h.set(sw, x, y-half, alive)
+	default:
+		se = h.set(se, x-half, y-half, alive)
+	}
+
+	return h.join(nw, ne, sw, se)
+}
+
+// x and y are relative to the top left corner of n
+func (n *node) get(x, y int) bool {
+	if n.level == 0 {
+		return n.alive
+	}
+	if n.population == 0 {
+		return false
+	}
+
+	half := 1 << (n.level - 1)
+
+	switch {
+	case x < half && y < half:
+		return n.nw.get(x, y)
+	case y < half:
+		return n.ne.get(x-half, y)
+	case x < half:
+		return n.sw.get(x, y-half)
+	default:
+		return n.se.get(x-half, y-half)
+	}
+}
+
+// originX and originY are the world coords of the top left corner of n
+func (h *HashLife) forEachAlive(n *node, originX, originY int, min, max Point, callback func(coords Point)) {
+	if n.population == 0 {
+		return
+	}
+
+	size := 1 << n.level
+	if originX > max.X || originY > max.Y || originX+size-1 < min.X || originY+size-1 < min.Y {
+		return
+	}
+
+	if n.level == 0 {
+		callback(Point{X: originX, Y: originY})
+		return
+	}
+
+	half := size >> 1
+	h.forEachAlive(n.nw, originX, originY, min, max, callback)
+	h.forEachAlive(n.ne, originX+half, originY, min, max, callback)
+	h.forEachAlive(n.sw, originX, originY+half, min, max, callback)
+	h.forEachAlive(n.se, originX+half, originY+half, min, max, callback)
+}
+
+//* Stepping
+
+// Returns the center half of n, advanced 2^min(stepExponent, level-2) generations
+func (h *HashLife) result(n *node) *node {
+	if n.result != nil {
+		return n.result
+	}
+
+	if n.level == 2 {
+		n.result = h.stepLeaves(n)
+		return n.result
+	}
+
+	// Nine overlapping subnodes, one level down
+	n00, n01, n02 := n.nw, h.horizontalCenter(n.nw, n.ne), n.ne
+	n10, n11, n12 := h.verticalCenter(n.nw, n.sw), h.center(n), h.verticalCenter(n.ne, n.se)
+	n20, n21, n22 := n.sw, h.horizontalCenter(n.sw, n.se), n.se
+
+	// At full speed the subnodes are advanced as well, which doubles the generations covered
+	advance := h.center
+	if h.stepExponent >= n.level-2 {
+		advance = h.result
+	}
+
+	r00, r01, r02 := advance(n00), advance(n01), advance(n02)
+	r10, r11, r12 := advance(n10), advance(n11), advance(n12)
+	r20, r21, r22 := advance(n20), advance(n21), advance(n22)
+
+	n.result = h.join(
+		h.result(h.join(r00, r01, r10, r11)),
+		h.result(h.join(r01, r02, r11, r12)),
+		h.result(h.join(r10, r11, r20, r21)),
+		h.result(h.join(r11, r12, r21, r22)),
+	)
+
+	return n.result
+}
+
+// Returns the center half of n without advancing it
+func (h *HashLife) center(n *node) *node {
+	return h.join(n.nw.se, n.ne.sw, n.sw.ne, n.se.nw)
+}
+
+// Returns the node straddling the border between w and e
+func (h *HashLife) horizontalCenter(w, e *node) *node {
+	return h.join(w.ne, e.nw, w.se, e.sw)
+}
+
+// Returns the node straddling the border between n and s
+func (h *HashLife) verticalCenter(n, s *node) *node {
+	return h.join(n.sw, n.se, s.nw, s.ne)
+}
+
+// Applies the rule directly to the center 2x2 cells of a 4x4 node
+func (h *HashLife) stepLeaves(n *node) *node {
+	var cells [4][4]bool
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 4; y++ {
+			cells[x][y] = n.get(x, y)
+		}
+	}
+
+	next := func(cx, cy int) *node {
+		var neighbourhood int
+		for x := 0; x < 3; x++ {
+			for y := 0; y < 3; y++ {
+				if cells[cx-1+x][cy-1+y] {
+					neighbourhood |= 1 << (x*3 + y)
+				}
+			}
+		}
+
+		if h.rule[neighbourhood] {
+			return aliveLeaf
+		}
+
+		return deadLeaf
+	}
+
+	return h.join(next(1, 1), next(2, 1), next(1, 2), next(2, 2))
+}
@@ -0,0 +1,115 @@
+package automaton
+
+import (
+	"math"
+	"testing"
+)
+
+var glider = []Point{{X: 1, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}
+
+func newGliderHashLife(t *testing.T) *HashLife {
+	t.Helper()
+
+	h, err := NewHashLife(mustParseRule(t, "B3/S23"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, coords := range glider {
+		h.Set(coords, true)
+	}
+
+	return h
+}
+
+// Returns every live cell of the universe
+func hashLifeCells(h *HashLife) map[Point]bool {
+	cells := make(map[Point]bool)
+	h.ForEachAlive(Point{X: math.MinInt64, Y: math.MinInt64}, Point{X: math.MaxInt64, Y: math.MaxInt64}, func(coords Point) {
+		cells[coords] = true
+	})
+
+	return cells
+}
+
+func TestHashLifeMatchesSparseGrid(t *testing.T) {
+	for _, exponent := range []uint{0, 1, 3, 5} {
+		h := newGliderHashLife(t)
+		h.SetStepExponent(exponent)
+
+		grid := NewSparseGrid()
+		for _, coords := range glider {
+			NewDot(coords, grid)
+		}
+
+		for step := 1; step <= 4; step++ {
+			if err := h.Step(); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 1<<exponent; i++ {
+				grid.Convolve(mustParseRule(t, "B3/S23"))
+			}
+
+			cells := hashLifeCells(h)
+			if len(cells) != grid.NumUsedCells() {
+				t.Fatalf("exponent %d, step %d: %d live cells, expected %d", exponent, step, len(cells), grid.NumUsedCells())
+			}
+			grid.ForEach(func(dot *Dot) {
+				if !cells[dot.Position()] || !h.Get(dot.Position()) {
+					t.Fatalf("exponent %d, step %d: cell %v should be alive", exponent, step, dot.Position())
+				}
+			})
+		}
+	}
+}
+
+// A glider flies away from the origin, which grows the root until its coords would no longer fit in an int
+func TestHashLifeStopsAtMaxLevel(t *testing.T) {
+	h := newGliderHashLife(t)
+	h.SetStepExponent(70)
+	if h.StepExponent() != MAX_HASHLIFE_STEP_EXPONENT {
+		t.Fatalf("step exponent is %d, expected it lowered to %d", h.StepExponent(), MAX_HASHLIFE_STEP_EXPONENT)
+	}
+
+	for step := 1; ; step++ {
+		if step > 100 {
+			t.Fatal("the glider never reached the edge of the universe")
+		}
+
+		generation := h.Generation()
+
+		if err := h.Step(); err != nil {
+			if h.Generation().Cmp(generation) != 0 {
+				t.Fatal("a failed step should not advance the generation")
+			}
+			break
+		}
+
+		if h.root.level > MAX_HASHLIFE_LEVEL {
+			t.Fatalf("step %d: root grew to level %d", step, h.root.level)
+		}
+
+		cells := hashLifeCells(h)
+		if len(cells) != 5 || h.Population() != 5 {
+			t.Fatalf("step %d: found %d of the glider's %d cells", step, len(cells), h.Population())
+		}
+		for coords := range cells {
+			if !h.Get(coords) {
+				t.Fatalf("step %d: Get(%v) is false for a live cell", step, coords)
+			}
+		}
+	}
+
+	// The glider is still where the last step left it
+	if cells := hashLifeCells(h); len(cells) != 5 {
+		t.Fatalf("found %d live cells after the failed step, expected 5", len(cells))
+	}
+}
+
+func TestHashLifeRejectsB0(t *testing.T) {
+	for _, rule := range []string{"B03/S23", "B0/S8", "B0123478/S34678"} {
+		if _, err := NewHashLife(mustParseRule(t, rule)); err == nil {
+			t.Errorf("expected %s to be rejected", rule)
+		}
+	}
+}
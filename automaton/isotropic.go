@@ -0,0 +1,270 @@
+package automaton
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// The 8 neighbours of a 3x3 window as a ring, starting north and going clockwise
+// Bit i of a ring index is set when neighbour i is alive
+var ringOffsets = [8]Point{
+	{X: 1, Y: 0}, // N
+	{X: 2, Y: 0}, // NE
+	{X: 2, Y: 1}, // E
+	{X: 2, Y: 2}, // SE
+	{X: 1, Y: 2}, // S
+	{X: 0, Y: 2}, // SW
+	{X: 0, Y: 1}, // W
+	{X: 0, Y: 0}, // NW
+}
+
+// Hensel letters for 1 to 4 live neighbours, each with one example of its shape as ring positions
+// 5 to 7 neighbours use the letters of the complement, e.g. 5c is everything but 3c
+var henselShapes = [5]map[byte][]int{
+	1: {'c': {1}, 'e': {0}},
+	2: {'c': {1, 3}, 'e': {0, 2}, 'k': {0, 3}, 'a': {0, 1}, 'i': {0, 4}, 'n': {1, 5}},
+	3: {
+		'c': {1, 3, 5}, 'e': {0, 2, 4}, 'k': {0, 2, 5}, 'a': {0, 1, 2}, 'i': {0, 1, 7},
+		'n': {0, 1, 3}, 'y': {0, 3, 5}, 'q': {0, 1, 5}, 'j': {0, 1, 6}, 'r': {0, 1, 4},
+	},
+	4: {
+		'c': {1, 3, 5, 7}, 'e': {0, 2, 4, 6}, 'k': {0, 1, 3, 6}, 'a': {0, 1, 2, 3}, 'i': {0, 1, 3, 4},
+		'n': {0, 1, 3, 7}, 'y': {0, 1, 3, 5}, 'q': {0, 1, 2, 5}, 'j': {0, 1, 4, 6}, 'r': {0, 1, 2, 4},
+		't': {0, 1, 4, 7}, 'w': {0, 1, 5, 6}, 'z': {0, 1, 4, 5},
+	},
+}
+
+// Canonical letter order within a count
+const HENSEL_LETTERS = "cekainyqjrtwz"
+
+// The Hensel letter of every ring index, 0 for counts without letters (0 and 8)
+var henselLetters = buildHenselLetters()
+
+func buildHenselLetters() [256]byte {
+	var letters [256]byte
+
+	for count := 1; count <= 4; count++ {
+		for letter, shape := range henselShapes[count] {
+			var ring int
+			for _, position := range shape {
+				ring |= 1 << position
+			}
+
+			for _, symmetric := range ringSymmetries(ring) {
+				letters[symmetric] = letter
+				// The complement has 8-count neighbours and the same letter
+				if count < 4 {
+					letters[^symmetric&0xff] = letter
+				}
+			}
+		}
+	}
+
+	return letters
+}
+
+// Returns the ring index rotated by 90 degree steps, and mirrored
+func ringSymmetries(ring int) []int {
+	var symmetries []int
+
+	for rotation := 0; rotation < 8; rotation += 2 {
+		var rotated, mirrored int
+		for position := 0; position < 8; position++ {
+			if ring&(1<<position) != 0 {
+				rotated |= 1 << ((position + rotation) % 8)
+				mirrored |= 1 << ((8 - position + rotation) % 8)
+			}
+		}
+
+		symmetries = append(symmetries, rotated, mirrored)
+	}
+
+	return symmetries
+}
+
+// Returns the letters a count can have, in canonical order
+func henselLettersFor(count int) string {
+	if count > 4 {
+		count = 8 - count
+	}
+	if count == 0 {
+		return ""
+	}
+
+	var letters strings.Builder
+	for i := 0; i < len(HENSEL_LETTERS); i++ {
+		if _, ok := henselShapes[count][HENSEL_LETTERS[i]]; ok {
+			letters.WriteByte(HENSEL_LETTERS[i])
+		}
+	}
+
+	return letters.String()
+}
+
+//* -------------------------
+//* ISOTROPIC RULE
+//* -------------------------
+// Isotropic non-totalistic rule, which can also tell the shape of the live neighbours apart (e.g. corners from edges),
+// as long as shapes that are rotations or mirror images of each other are treated the same
+// Written in Hensel notation like B2-a/S12, where every count can be followed by the letters of the shapes it applies to,
+// or by a minus and the letters it does not apply to
+type IsotropicRule struct {
+	Kernel
+	birth, survival [256]bool // Indexed by ring index
+}
+
+// birth and survival are written like "2-a3" or "12"
+func NewIsotropicRule(birth, survival string) (*IsotropicRule, error) {
+	rule := &IsotropicRule{Kernel: Kernel{size: 3}}
+
+	var err error
+	if rule.birth, err = parseHensel(birth); err != nil {
+		return nil, fmt.Errorf("invalid birth %q: %v", birth, err)
+	}
+	if rule.survival, err = parseHensel(survival); err != nil {
+		return nil, fmt.Errorf("invalid survival %q: %v", survival, err)
+	}
+
+	return rule, nil
+}
+
+func (r *IsotropicRule) ApplyKernel(win *Window) *Dot {
+	ring := ringIndex(win)
+
+	if win.Center() != nil {
+		if r.survival[ring] {
+			return win.Center()
+		}
+
+		return nil
+	}
+
+	if r.birth[ring] {
+		return NewDot(win.GridCoords(), nil)
+	}
+
+	return nil
+}
+
+func (r *IsotropicRule) Size() int {
+	return r.size
+}
+
+// Returns the rule in Hensel notation, using the shortest form for every count
+func (r *IsotropicRule) String() string {
+	return fmt.Sprintf("B%s/S%s", formatHensel(r.birth), formatHensel(r.survival))
+}
+
+// Returns the ring index of the live neighbours in a 3x3 window
+func ringIndex(win *Window) int {
+	var ring int
+
+	for position, offset := range ringOffsets {
+		if win.Get(offset) != nil {
+			ring |= 1 << position
+		}
+	}
+
+	return ring
+}
+
+// Parses counts in Hensel notation like "2-a3" into the ring indexes they apply to
+func parseHensel(body string) ([256]bool, error) {
+	var included [256]bool
+
+	body = strings.ToLower(body)
+
+	for i := 0; i < len(body); {
+		if body[i] < '0' || body[i] > '8' {
+			return included, fmt.Errorf("expected a neighbour count between 0 and 8, got %q", body[i])
+		}
+
+		count := int(body[i] - '0')
+		i++
+
+		negated := i < len(body) && body[i] == '-'
+		if negated {
+			i++
+		}
+
+		start := i
+		for i < len(body) && strings.IndexByte(HENSEL_LETTERS, body[i]) >= 0 {
+			i++
+		}
+		letters := body[start:i]
+
+		if negated && letters == "" {
+			return included, fmt.Errorf("count %d has a minus without any letters after it", count)
+		}
+
+		for _, letter := range letters {
+			if !strings.ContainsRune(henselLettersFor(count), letter) {
+				return included, fmt.Errorf("count %d has no shape %q", count, letter)
+			}
+		}
+
+		for ring := range included {
+			if bits.OnesCount(uint(ring)) != count {
+				continue
+			}
+
+			// Without letters every shape of the count applies
+			applies := letters == "" || strings.IndexByte(letters, henselLetters[ring]) >= 0
+			if negated {
+				applies = !applies
+			}
+
+			if applies {
+				included[ring] = true
+			}
+		}
+	}
+
+	return included, nil
+}
+
+// Formats the included ring indexes in Hensel notation, the inverse of parseHensel
+func formatHensel(included [256]bool) string {
+	var sb strings.Builder
+
+	for count := 0; count <= 8; count++ {
+		var with, without strings.Builder
+
+		for _, letter := range henselLettersFor(count) {
+			if henselIncludes(included, count, byte(letter)) {
+				with.WriteRune(letter)
+			} else {
+				without.WriteRune(letter)
+			}
+		}
+
+		switch {
+		case count == 0 || count == 8:
+			if henselIncludes(included, count, 0) {
+				sb.WriteByte(byte('0' + count))
+			}
+		case without.Len() == 0:
+			sb.WriteByte(byte('0' + count))
+		case with.Len() == 0:
+			// Not included at all
+		case with.Len() <= without.Len():
+			sb.WriteString(fmt.Sprintf("%d%s", count, with.String()))
+		default:
+			sb.WriteString(fmt.Sprintf("%d-%s", count, without.String()))
+		}
+	}
+
+	return sb.String()
+}
+
+// Whether the shape with the given count and letter is included, every ring index of a shape is included or none are
+func henselIncludes(included [256]bool, count int, letter byte) bool {
+	for ring := range included {
+		if bits.OnesCount(uint(ring)) == count && henselLetters[ring] == letter {
+			return included[ring]
+		}
+	}
+
+	return false
+}
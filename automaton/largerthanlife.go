@@ -0,0 +1,303 @@
+package automaton
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+const (
+	MAX_LTL_RADIUS = 500
+)
+
+//* -------------------------
+//* LARGER THAN LIFE RULE
+//* -------------------------
+// Life-like rule with a bigger neighbourhood, where birth and survival depend on the live cells within a radius
+// Written like R5,C0,M1,S34..58,B34..45,NM (Bosco's rule):
+// R is the radius, C the number of states (0 for 2, more like in a Generations rule), M1 counts the middle cell itself,
+// S and B are inclusive ranges of counts, and N is the neighbourhood, M for Moore (square) or N for von Neumann (diamond)
+type LargerThanLifeRule struct {
+	Kernel
+	radius                   int
+	states                   int
+	middle                   bool
+	vonNeumann               bool
+	survivalMin, survivalMax int
+	birthMin, birthMax       int
+	fills                    []color.Color // Indexed by state, like GenerationsRule
+}
+
+func NewLargerThanLifeRule(radius, states int, middle, vonNeumann bool, survivalMin, survivalMax, birthMin, birthMax int) (*LargerThanLifeRule, error) {
+	if !between(radius, 1, MAX_LTL_RADIUS) {
+		return nil, fmt.Errorf("radius %d is not between 1 and %d", radius, MAX_LTL_RADIUS)
+	}
+	if survivalMin > survivalMax || birthMin > birthMax {
+		return nil, fmt.Errorf("ranges must go from low to high, got S%d..%d and B%d..%d", survivalMin, survivalMax, birthMin, birthMax)
+	}
+
+	// C0 and C1 mean the same as C2
+	if states < 2 {
+		states = 2
+	}
+
+	rule := &LargerThanLifeRule{
+		Kernel:      Kernel{size: radius*2 + 1},
+		radius:      radius,
+		states:      states,
+		middle:      middle,
+		vonNeumann:  vonNeumann,
+		survivalMin: survivalMin,
+		survivalMax: survivalMax,
+		birthMin:    birthMin,
+		birthMax:    birthMax,
+	}
+	rule.fills = append([]color.Color{nil}, Gradient(dotFill, dyingFill, states-1)...)
+
+	return rule, nil
+}
+
+// Counts by scanning the whole window, which is only used when there are no running sums for the grid
+func (r *LargerThanLifeRule) ApplyKernel(win *Window) *Dot {
+	var count int
+
+	for x := -r.radius; x <= r.radius; x++ {
+		for y := -r.radius; y <= r.radius; y++ {
+			if r.vonNeumann && abs(x)+abs(y) > r.radius {
+				continue
+			}
+			if x == 0 && y == 0 && !r.middle {
+				continue
+			}
+
+			if dot := win.Get(Point{X: x + r.radius, Y: y + r.radius}); dot != nil && dot.State() == 1 {
+				count++
+			}
+		}
+	}
+
+	return r.next(win.Center(), win.GridCoords(), count)
+}
+
+// Counts with the running sums made by Prepare
+func (r *LargerThanLifeRule) ApplyCell(grid *Grid, coords Point) *Dot {
+	sums, ok := grid.Prepared().(*summedArea)
+	if !ok || sums.margin < r.radius {
+		return r.ApplyKernel(NewWindow(grid, coords, r.Size()))
+	}
+
+	var count int
+
+	if r.vonNeumann {
+		// One row of the diamond at a time
+		for y := -r.radius; y <= r.radius; y++ {
+			span := r.radius - abs(y)
+			count += sums.count(coords.X-span, coords.Y+y, coords.X+span, coords.Y+y)
+		}
+	} else {
+		count = sums.count(coords.X-r.radius, coords.Y-r.radius, coords.X+r.radius, coords.Y+r.radius)
+	}
+
+	center := grid.Lookup(coords)
+	if !r.middle && center != nil && center.State() == 1 {
+		count--
+	}
+
+	return r.next(center, coords, count)
+}
+
+// Builds running sums of the live cells in the grid, so every count is a few lookups no matter the radius
+func (r *LargerThanLifeRule) Prepare(grid *Grid) interface{} {
+	return newSummedArea(grid, r.radius)
+}
+
+func (r *LargerThanLifeRule) Size() int {
+	return r.size
+}
+
+func (r *LargerThanLifeRule) States() int {
+	return r.states
+}
+
+// Returns the fill of every state, index 0 (dead) is nil
+func (r *LargerThanLifeRule) Fills() []color.Color {
+	return r.fills
+}
+
+func (r *LargerThanLifeRule) Palette() Palette {
+	return dyingPalette(r.fills)
+}
+
+// Returns the rule in Larger than Life notation, e.g. R5,C0,M1,S34..58,B34..45,NM
+func (r *LargerThanLifeRule) String() string {
+	states, middle, neighbourhood := 0, 0, "M"
+	if r.states > 2 {
+		states = r.states
+	}
+	if r.middle {
+		middle = 1
+	}
+	if r.vonNeumann {
+		neighbourhood = "N"
+	}
+
+	return fmt.Sprintf("R%d,C%d,M%d,S%d..%d,B%d..%d,N%s", r.radius, states, middle, r.survivalMin, r.survivalMax, r.birthMin, r.birthMax, neighbourhood)
+}
+
+// Returns the next value of a cell, given the number of live cells in its neighbourhood
+func (r *LargerThanLifeRule) next(center *Dot, coords Point, count int) *Dot {
+	if center == nil {
+		if between(count, r.birthMin, r.birthMax) {
+			return NewDot(coords, nil)
+		}
+
+		return nil
+	}
+
+	if center.State() == 1 && between(count, r.survivalMin, r.survivalMax) {
+		return center
+	}
+
+	next := center.State() + 1
+	if next >= r.states {
+		return nil
+	}
+
+	return NewStateDot(coords, next, r.fills[next], nil)
+}
+
+// Parses a rule like R5,C0,M1,S34..58,B34..45,NM, C and M can be left out and default to 0
+func parseLargerThanLife(rule string) (*LargerThanLifeRule, error) {
+	var radius, states, middle int
+	var survivalMin, survivalMax, birthMin, birthMax int
+	var vonNeumann, hasRadius, hasSurvival, hasBirth bool
+
+	for _, part := range strings.Split(strings.ToUpper(strings.ReplaceAll(rule, " ", "")), ",") {
+		if part == "" {
+			return nil, fmt.Errorf("invalid rule %q: empty part", rule)
+		}
+
+		var err error
+		value := part[1:]
+
+		switch part[0] {
+		case 'R':
+			radius, err = strconv.Atoi(value)
+			hasRadius = true
+		case 'C':
+			states, err = strconv.Atoi(value)
+		case 'M':
+			middle, err = strconv.Atoi(value)
+			if err == nil && middle != 0 && middle != 1 {
+				err = fmt.Errorf("M must be 0 or 1, got %d", middle)
+			}
+		case 'S':
+			survivalMin, survivalMax, err = parseRange(value)
+			hasSurvival = true
+		case 'B':
+			birthMin, birthMax, err = parseRange(value)
+			hasBirth = true
+		case 'N':
+			switch value {
+			case "M":
+				vonNeumann = false
+			case "N":
+				vonNeumann = true
+			default:
+				err = fmt.Errorf("unknown neighbourhood %q, expected NM or NN", part)
+			}
+		default:
+			err = fmt.Errorf("unknown part %q", part)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+		}
+	}
+
+	if !hasRadius || !hasSurvival || !hasBirth {
+		return nil, fmt.Errorf("invalid rule %q: R, S and B are required", rule)
+	}
+
+	ltl, err := NewLargerThanLifeRule(radius, states, middle == 1, vonNeumann, survivalMin, survivalMax, birthMin, birthMax)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+	}
+
+	return ltl, nil
+}
+
+// Parses an inclusive range like 34..58, or a single count like 3
+func parseRange(value string) (int, int, error) {
+	bounds := strings.SplitN(value, "..", 2)
+
+	min, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(bounds) == 1 {
+		return min, min, nil
+	}
+
+	max, err := strconv.Atoi(bounds[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return min, max, nil
+}
+
+//* -------------------------
+//* SUMMED AREA
+//* -------------------------
+// Running sums of the live cells in a grid, so the live cells in any rectangle can be counted with 4 lookups
+// Covers the grid plus a margin, read through the boundary, so windows reaching past the edges see what Lookup would
+type summedArea struct {
+	margin        int   // Cells covered past the grid's extent on every side
+	origin        Point // Grid coords of the first cell covered
+	width, height int
+	sums          []int32 // sums[x*(height+1)+y] is the number of live cells above and left of origin+(x, y)
+}
+
+func newSummedArea(grid *Grid, margin int) *summedArea {
+	min, max := grid.Extent()
+	if grid.Unbounded() {
+		// Cells within reach of a live cell are visited, and their windows reach further still
+		margin *= 2
+	}
+
+	sa := &summedArea{
+		margin: margin,
+		origin: Point{X: min.X - margin, Y: min.Y - margin},
+		width:  max.X - min.X + 1 + margin*2,
+		height: max.Y - min.Y + 1 + margin*2,
+	}
+	sa.sums = make([]int32, (sa.width+1)*(sa.height+1))
+
+	stride := sa.height + 1
+	for x := 0; x < sa.width; x++ {
+		var column int32
+
+		for y := 0; y < sa.height; y++ {
+			if dot := grid.Lookup(Point{X: sa.origin.X + x, Y: sa.origin.Y + y}); dot != nil && dot.State() == 1 {
+				column++
+			}
+
+			sa.sums[(x+1)*stride+y+1] = sa.sums[x*stride+y+1] + column
+		}
+	}
+
+	return sa
+}
+
+// Returns the number of live cells from x0, y0 to x1, y1 (inclusive, in grid coords)
+// Cells outside the covered area count as dead
+func (sa *summedArea) count(x0, y0, x1, y1 int) int {
+	x0, x1 = clamp(x0-sa.origin.X, 0, sa.width), clamp(x1-sa.origin.X+1, 0, sa.width)
+	y0, y1 = clamp(y0-sa.origin.Y, 0, sa.height), clamp(y1-sa.origin.Y+1, 0, sa.height)
+
+	stride := sa.height + 1
+
+	return int(sa.sums[x1*stride+y1] - sa.sums[x0*stride+y1] - sa.sums[x1*stride+y0] + sa.sums[x0*stride+y0])
+}
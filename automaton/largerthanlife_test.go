@@ -0,0 +1,70 @@
+package automaton
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestLargerThanLifeSumsMatchWindows(t *testing.T) {
+	for _, rule := range []string{"R2,C0,M1,S3..5,B3..4,NM", "R3,C3,M0,S4..9,B5..7,NN"} {
+		for _, boundary := range allBoundaries {
+			t.Run(fmt.Sprintf("%s/%v", rule, boundary), func(t *testing.T) {
+				conv := mustParseRule(t, rule)
+				ltl := conv.(*LargerThanLifeRule)
+
+				grid := NewGrid(19, 23)
+				grid.SetBoundary(boundary)
+				randomSoup(grid, 4, 0.4)
+
+				// Without Prepare, ApplyCell falls back to scanning the window
+				prepared := ltl.Prepare(grid)
+				for x := 0; x < grid.Width(); x++ {
+					for y := 0; y < grid.Height(); y++ {
+						coords := Point{X: x, Y: y}
+
+						grid.prepared = nil
+						want := ltl.ApplyCell(grid, coords)
+						grid.prepared = prepared
+						got := ltl.ApplyCell(grid, coords)
+
+						if (want == nil) != (got == nil) || (want != nil && want.State() != got.State()) {
+							t.Fatalf("cell %v is %v with running sums, expected %v", coords, got, want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// One rule convolving several grids at the same time must give each grid what it would get on its own
+func TestLargerThanLifeSharedBetweenGrids(t *testing.T) {
+	const rule = "R2,C0,M1,S3..5,B3..4,NM"
+	shared := mustParseRule(t, rule)
+
+	grids := make([]*Grid, 4)
+	alone := make([]*Grid, len(grids))
+	for i := range grids {
+		grids[i], alone[i] = NewGrid(30+i*7, 20), NewGrid(30+i*7, 20)
+		randomSoup(grids[i], int64(i), 0.3)
+		randomSoup(alone[i], int64(i), 0.3)
+	}
+
+	for generation := 1; generation <= 10; generation++ {
+		var wg sync.WaitGroup
+		for _, grid := range grids {
+			wg.Add(1)
+			go func(grid *Grid) {
+				defer wg.Done()
+				grid.Convolve(shared)
+			}(grid)
+		}
+		wg.Wait()
+
+		for i := range grids {
+			alone[i].Convolve(mustParseRule(t, rule))
+			compareGrids(t, generation, alone[i], grids[i])
+		}
+	}
+}
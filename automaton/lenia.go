@@ -0,0 +1,249 @@
+package automaton
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Maps a potential to a growth between -1 and 1
+type GrowthFunction func(potential float64) float64
+
+// Grows most at mu, falling off like a bell curve of width sigma
+func GaussianGrowth(mu, sigma float64) GrowthFunction {
+	return func(potential float64) float64 {
+		return 2*math.Exp(-(potential-mu)*(potential-mu)/(2*sigma*sigma)) - 1
+	}
+}
+
+// Like GaussianGrowth, but exactly -1 further than 3 sigma from mu
+func PolynomialGrowth(mu, sigma float64) GrowthFunction {
+	return func(potential float64) float64 {
+		falloff := math.Max(0, 1-(potential-mu)*(potential-mu)/(9*sigma*sigma))
+		return 2*math.Pow(falloff, 4) - 1
+	}
+}
+
+// Presets for ParseContinuousRule, which can still be tweaked with parameters
+var NamedContinuousRules = map[string]string{
+	"orbium":     "lenia:R=13,mu=0.15,sigma=0.015,dt=0.1,peaks=1",
+	"smoothlife": "smoothlife:ra=12,b1=0.278,b2=0.365,d1=0.267,d2=0.445,an=0.028,am=0.147,dt=1",
+}
+
+//* -------------------------
+//* LENIA RULE
+//* -------------------------
+// Every cell grows by dt times the growth of its potential through a ring kernel
+type LeniaRule struct {
+	radius     int
+	peaks      []float64
+	mu, sigma  float64
+	dt         float64
+	polynomial bool
+
+	kernel *ContinuousKernel
+	growth GrowthFunction
+}
+
+func NewLeniaRule(radius int, peaks []float64, mu, sigma, dt float64, polynomial bool) (*LeniaRule, error) {
+	if radius < 1 {
+		return nil, fmt.Errorf("radius must be at least 1, got %d", radius)
+	}
+	if len(peaks) == 0 {
+		return nil, fmt.Errorf("the kernel needs at least one peak")
+	}
+	if dt <= 0 || dt > 1 {
+		return nil, fmt.Errorf("dt must be above 0 and at most 1, got %v", dt)
+	}
+	if sigma <= 0 {
+		return nil, fmt.Errorf("sigma must be above 0, got %v", sigma)
+	}
+
+	rule := &LeniaRule{radius: radius, peaks: peaks, mu: mu, sigma: sigma, dt: dt, polynomial: polynomial}
+	rule.kernel = RingKernel(radius, peaks)
+
+	rule.growth = GaussianGrowth(mu, sigma)
+	if polynomial {
+		rule.growth = PolynomialGrowth(mu, sigma)
+	}
+
+	return rule, nil
+}
+
+func (r *LeniaRule) Kernels() []*ContinuousKernel {
+	return []*ContinuousKernel{r.kernel}
+}
+
+func (r *LeniaRule) Update(value float64, potentials []float64) float64 {
+	return value + r.dt*r.growth(potentials[0])
+}
+
+func (r *LeniaRule) Radius() int {
+	return r.radius
+}
+
+// Returns the rule in the form ParseContinuousRule reads
+func (r *LeniaRule) String() string {
+	peaks := make([]string, len(r.peaks))
+	for i, peak := range r.peaks {
+		peaks[i] = strconv.FormatFloat(peak, 'g', -1, 64)
+	}
+
+	growth := "gaussian"
+	if r.polynomial {
+		growth = "polynomial"
+	}
+
+	return fmt.Sprintf("lenia:R=%d,mu=%g,sigma=%g,dt=%g,peaks=%s,growth=%s", r.radius, r.mu, r.sigma, r.dt, strings.Join(peaks, "/"), growth)
+}
+
+//* -------------------------
+//* SMOOTHLIFE RULE
+//* -------------------------
+// Life on a continuum: the filling of an inner disk (m) decides whether the filling of the ring around it (n) gives birth or survival
+// With a dt of 1 every cell becomes its transition value, smaller steps move towards it
+type SmoothLifeRule struct {
+	outer, inner   float64
+	b1, b2, d1, d2 float64 // Birth and death intervals of n
+	alphaN, alphaM float64 // Steepness of the transitions
+	dt             float64
+
+	disk, ring *ContinuousKernel
+}
+
+// The inner disk is a third of the outer radius, as in the original
+func NewSmoothLifeRule(outer, b1, b2, d1, d2, alphaN, alphaM, dt float64) (*SmoothLifeRule, error) {
+	if outer < 3 {
+		return nil, fmt.Errorf("the outer radius must be at least 3, got %v", outer)
+	}
+	if dt <= 0 || dt > 1 {
+		return nil, fmt.Errorf("dt must be above 0 and at most 1, got %v", dt)
+	}
+
+	rule := &SmoothLifeRule{
+		outer: outer, inner: outer / 3,
+		b1: b1, b2: b2, d1: d1, d2: d2,
+		alphaN: alphaN, alphaM: alphaM,
+		dt: dt,
+	}
+	rule.disk = AnnulusKernel(0, rule.inner)
+	rule.ring = AnnulusKernel(rule.inner, rule.outer)
+
+	return rule, nil
+}
+
+func (r *SmoothLifeRule) Kernels() []*ContinuousKernel {
+	return []*ContinuousKernel{r.disk, r.ring}
+}
+
+func (r *SmoothLifeRule) Update(value float64, potentials []float64) float64 {
+	m, n := potentials[0], potentials[1]
+
+	// Alive cells (m near 1) use the death interval, dead ones the birth interval
+	alive := sigmoid(m, 0.5, r.alphaM)
+	low := r.b1*(1-alive) + r.d1*alive
+	high := r.b2*(1-alive) + r.d2*alive
+	transition := sigmoid(n, low, r.alphaN) * (1 - sigmoid(n, high, r.alphaN))
+
+	if r.dt == 1 {
+		return transition
+	}
+
+	return value + r.dt*(2*transition-1)
+}
+
+func (r *SmoothLifeRule) Radius() int {
+	return r.ring.Radius()
+}
+
+// Returns the rule in the form ParseContinuousRule reads
+func (r *SmoothLifeRule) String() string {
+	return fmt.Sprintf("smoothlife:ra=%g,b1=%g,b2=%g,d1=%g,d2=%g,an=%g,am=%g,dt=%g", r.outer, r.b1, r.b2, r.d1, r.d2, r.alphaN, r.alphaM, r.dt)
+}
+
+// Smooth step from 0 to 1 around a, with width alpha
+func sigmoid(x, a, alpha float64) float64 {
+	return 1 / (1 + math.Exp(-(x-a)*4/alpha))
+}
+
+// Parses a continuous rule like lenia:R=13,mu=0.15,sigma=0.015,dt=0.1,peaks=1 or one of the NamedContinuousRules
+// Parameters that are left out keep the values of the orbium or smoothlife presets
+func ParseContinuousRule(rule string) (ContinuousRule, error) {
+	rule = strings.ToLower(strings.ReplaceAll(rule, " ", ""))
+	if named, ok := NamedContinuousRules[rule]; ok {
+		rule = strings.ToLower(named)
+	}
+
+	family, params := rule, ""
+	if colon := strings.IndexByte(rule, ':'); colon >= 0 {
+		family, params = rule[:colon], rule[colon+1:]
+	}
+
+	// Start from the preset of the family, and override whatever is given
+	defaults := map[string]string{"lenia": "orbium", "smoothlife": "smoothlife"}
+	preset, ok := defaults[family]
+	if !ok {
+		return nil, fmt.Errorf("invalid rule %q: unknown family %q, expected lenia or smoothlife", rule, family)
+	}
+
+	values := make(map[string]string)
+	for _, part := range []string{strings.ToLower(NamedContinuousRules[preset][len(family)+1:]), params} {
+		for _, pair := range strings.Split(part, ",") {
+			if pair == "" {
+				continue
+			}
+
+			equals := strings.IndexByte(pair, '=')
+			if equals < 0 {
+				return nil, fmt.Errorf("invalid rule %q: %q is not a key=value pair", rule, pair)
+			}
+
+			values[pair[:equals]] = pair[equals+1:]
+		}
+	}
+
+	floats := make(map[string]float64)
+	for key, value := range values {
+		if key == "peaks" || key == "growth" {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %s is not a number", rule, key)
+		}
+
+		floats[key] = number
+	}
+
+	var parsed ContinuousRule
+	var err error
+
+	if family == "lenia" {
+		var peaks []float64
+		for _, peak := range strings.Split(values["peaks"], "/") {
+			height, err := strconv.ParseFloat(peak, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q: peak %q is not a number", rule, peak)
+			}
+
+			peaks = append(peaks, height)
+		}
+
+		growth := values["growth"]
+		if growth != "" && growth != "gaussian" && growth != "polynomial" {
+			return nil, fmt.Errorf("invalid rule %q: unknown growth %q, expected gaussian or polynomial", rule, growth)
+		}
+
+		parsed, err = NewLeniaRule(int(floats["r"]), peaks, floats["mu"], floats["sigma"], floats["dt"], growth == "polynomial")
+	} else {
+		parsed, err = NewSmoothLifeRule(floats["ra"], floats["b1"], floats["b2"], floats["d1"], floats["d2"], floats["an"], floats["am"], floats["dt"])
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+	}
+
+	return parsed, nil
+}
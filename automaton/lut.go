@@ -0,0 +1,179 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	LUT_SIZE = 512 // One entry for every 3x3 neighbourhood
+)
+
+//* -------------------------
+//* LOOKUP TABLE
+//* -------------------------
+// Binary 3x3 rule that looks up the next value of the center in a table, instead of computing it
+// Entry x*3+y of the table is used when the cell at x, y in the window is alive, so bit 4 is the center
+type LUT struct {
+	Kernel
+	table     [LUT_SIZE]bool
+	hexagonal bool // Kept from the compiled rule, the table itself works either way
+}
+
+// Runs the rule once for every possible 3x3 neighbourhood, so it must be deterministic and binary (dot or no dot)
+// Bit x*3+y of the index is the cell at x, y in the window, so bit 4 is the center
+func CompileLUT(conv Convolver) (*LUT, error) {
+	if conv.Size() != 3 {
+		return nil, errors.New("only rules with a 'Size' of 3 can be probed")
+	}
+
+	// States other than alive never show up in the probes, palettes leave out the empty state
+	if multiState, ok := conv.(MultiStateRule); ok && len(multiState.Palette()) > 1 {
+		return nil, errors.New("only rules with 2 states can be probed")
+	}
+
+	// A table has one answer per neighbourhood, so chance can not come into it
+	if IsStochastic(conv) {
+		return nil, errors.New("only deterministic rules can be probed")
+	}
+
+	// Compiled rules already know every answer
+	if lut, ok := conv.(*LUT); ok {
+		return lut, nil
+	}
+
+	lut := &LUT{Kernel: Kernel{size: 3}, hexagonal: IsHexagonal(conv)}
+
+	for neighbourhood := range lut.table {
+		matrix := make([][]*Dot, 3)
+
+		for x := range matrix {
+			matrix[x] = make([]*Dot, 3)
+
+			for y := range matrix[x] {
+				if neighbourhood&(1<<(x*3+y)) != 0 {
+					matrix[x][y] = NewDot(*NewPoint(x-1, y-1), nil)
+				}
+			}
+		}
+
+		win := &Window{center: Point{}, size: 3, matrix: matrix}
+		result := conv.ApplyKernel(win)
+
+		// A table only has room for alive or dead
+		if result != nil && result.State() != 1 {
+			return nil, errors.New("only rules with 2 states can be probed")
+		}
+
+		lut.table[neighbourhood] = result != nil
+	}
+
+	return lut, nil
+}
+
+// Reads a table in the format of LUT.String
+func ParseLUT(encoded string) (*LUT, error) {
+	encoded = strings.TrimSpace(encoded)
+	if len(encoded) != LUT_SIZE/4 {
+		return nil, fmt.Errorf("lookup table must be %d hex digits, got %d", LUT_SIZE/4, len(encoded))
+	}
+
+	lut := &LUT{Kernel: Kernel{size: 3}}
+
+	for i, digit := range encoded {
+		nibble, err := strconv.ParseUint(string(digit), 16, 4)
+		if err != nil {
+			return nil, fmt.Errorf("lookup table has an invalid hex digit %q at %d", digit, i)
+		}
+
+		for bit := 0; bit < 4; bit++ {
+			lut.table[i*4+bit] = nibble&(1<<bit) != 0
+		}
+	}
+
+	return lut, nil
+}
+
+func (l *LUT) ApplyKernel(win *Window) *Dot {
+	if !l.table[neighbourhoodIndex(win)] {
+		return nil
+	}
+
+	// Surviving cells keep their dot, so they do not count as changed
+	if win.Center() != nil {
+		return win.Center()
+	}
+
+	return NewDot(win.GridCoords(), nil)
+}
+
+// Same as ApplyKernel, without building a window
+func (l *LUT) ApplyCell(grid *Grid, coords Point) *Dot {
+	var index int
+	for x := 0; x < 3; x++ {
+		for y := 0; y < 3; y++ {
+			if grid.Lookup(Point{X: coords.X + x - 1, Y: coords.Y + y - 1}) != nil {
+				index |= 1 << (x*3 + y)
+			}
+		}
+	}
+
+	if !l.table[index] {
+		return nil
+	}
+
+	if center := grid.Lookup(coords); center != nil {
+		return center
+	}
+
+	return NewDot(coords, nil)
+}
+
+func (l *LUT) Hexagonal() bool {
+	return l.hexagonal
+}
+
+func (l *LUT) Size() int {
+	return l.size
+}
+
+// Returns whether the center is alive in the next generation, given a neighbourhood index
+func (l *LUT) Lookup(neighbourhood int) bool {
+	return l.table[neighbourhood]
+}
+
+// Canonical form of the rule, since two rules with the same table always behave the same
+// Hex digit i holds entries 4i to 4i+3, lowest bit first
+func (l *LUT) String() string {
+	var sb strings.Builder
+
+	for i := 0; i < LUT_SIZE; i += 4 {
+		var nibble uint64
+		for bit := 0; bit < 4; bit++ {
+			if l.table[i+bit] {
+				nibble |= 1 << bit
+			}
+		}
+
+		sb.WriteString(strconv.FormatUint(nibble, 16))
+	}
+
+	return sb.String()
+}
+
+// Returns the table index of a 3x3 window's neighbourhood
+func neighbourhoodIndex(win *Window) int {
+	var index int
+
+	for x, col := range win.matrix {
+		for y, val := range col {
+			if val != nil {
+				index |= 1 << (x*3 + y)
+			}
+		}
+	}
+
+	return index
+}
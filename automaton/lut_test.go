@@ -0,0 +1,60 @@
+package automaton
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLUTRoundTrip(t *testing.T) {
+	rule := NewCustomGame2()
+
+	compiled, err := CompileLUT(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The string is the canonical form, so parsing it gives the same table and the same string back
+	parsed, err := ParseLUT(compiled.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.table != compiled.table {
+		t.Fatal("parsed table differs from the compiled one")
+	}
+	if parsed.String() != compiled.String() {
+		t.Fatalf("string changed after parsing: %s, expected %s", parsed, compiled)
+	}
+
+	for _, boundary := range allBoundaries {
+		t.Run(fmt.Sprint(boundary), func(t *testing.T) {
+			want, got := NewGrid(24, 18), NewGrid(24, 18)
+			for _, grid := range []*Grid{want, got} {
+				grid.SetBoundary(boundary)
+				randomSoup(grid, 3, 0.35)
+			}
+
+			for generation := 1; generation <= 20; generation++ {
+				want.Convolve(rule)
+				got.Convolve(parsed)
+
+				compareGrids(t, generation, want, got)
+			}
+		})
+	}
+}
+
+func TestCompileLUTRejectsRules(t *testing.T) {
+	for _, rule := range []string{"wireworld", "B2/S/C3", "R5,C0,M1,S34..58,B34..45,NM", "B3/S23:noise=0.1"} {
+		if _, err := CompileLUT(mustParseRule(t, rule)); err == nil {
+			t.Errorf("expected %s to be rejected", rule)
+		}
+	}
+}
+
+func TestParseLUTErrors(t *testing.T) {
+	for _, encoded := range []string{"", "0", fmt.Sprintf("%0127dg", 0)} {
+		if _, err := ParseLUT(encoded); err == nil {
+			t.Errorf("expected %q to be rejected", encoded)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Well known block rules, which ParseMargolusRule accepts by name (case insensitive)
+var NamedMargolusRules = map[string]string{
+	"bbm":      "MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15", // Billiard-ball machine
+	"critters": "MS,D15;14;13;3;11;5;6;1;7;9;10;2;12;4;8;0",
+	"tron":     "MS,D15;1;2;3;4;5;6;7;8;9;10;11;12;13;14;0",
+	"sand":     "MS,D0;4;8;12;4;12;12;13;8;12;12;14;12;13;14;15",
+}
+
+//* -------------------------
+//* MARGOLUS RULE
+//* -------------------------
+// Rule that turns every 2x2 block of cells into a new block as a whole, instead of looking at one cell at a time
+// A block is numbered by its live cells: 1 for the top left, 2 for the top right, 4 for the bottom left and 8 for the bottom right,
+// and entry n of the table is the block that block n turns into, like in MCell
+type MargolusRule struct {
+	table [16]int
+}
+
+func NewMargolusRule(table [16]int) (*MargolusRule, error) {
+	for block, next := range table {
+		if !between(next, 0, 15) {
+			return nil, fmt.Errorf("block %d turns into %d, which is not between 0 and 15", block, next)
+		}
+	}
+
+	return &MargolusRule{table: table}, nil
+}
+
+// Returns the block that block turns into
+func (r *MargolusRule) Apply(block int) int {
+	return r.table[block]
+}
+
+// Returns the rule in MCell notation, e.g. MS,D15;1;2;3;4;5;6;7;8;9;10;11;12;13;14;0 for Tron
+func (r *MargolusRule) String() string {
+	entries := make([]string, len(r.table))
+	for block, next := range r.table {
+		entries[block] = strconv.Itoa(next)
+	}
+
+	return "MS,D" + strings.Join(entries, ";")
+}
+
+// Parses a rule in MCell notation like MS,D15;1;2;3;4;5;6;7;8;9;10;11;12;13;14;0,
+// just the 16 entries separated by semicolons or commas, or one of the NamedMargolusRules
+func ParseMargolusRule(spec string) (*MargolusRule, error) {
+	spec = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(spec), " ", ""))
+	if named, ok := NamedMargolusRules[strings.ToLower(spec)]; ok {
+		spec = named
+	}
+
+	entries := strings.FieldsFunc(strings.TrimPrefix(strings.TrimPrefix(spec, "MS,"), "D"), func(char rune) bool {
+		return char == ';' || char == ','
+	})
+
+	if len(entries) != 16 {
+		return nil, fmt.Errorf("invalid block rule %q: expected 16 entries, got %d", spec, len(entries))
+	}
+
+	var table [16]int
+	for block, entry := range entries {
+		next, err := strconv.Atoi(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block rule %q: %q is not a number", spec, entry)
+		}
+
+		table[block] = next
+	}
+
+	rule, err := NewMargolusRule(table)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block rule %q: %v", spec, err)
+	}
+
+	return rule, nil
+}
+
+//* -------------------------
+//* MARGOLUS PARTITION
+//* -------------------------
+// Runs a block rule on a grid, which is cut into 2x2 blocks that shift one cell down and right every other generation,
+// so the blocks of one generation straddle the edges of the blocks of the one before
+// Blocks that reach past the edges wrap around if the grid's boundary does, and are left alone otherwise
+type MargolusPartition struct {
+	grid  *Grid
+	rule  *MargolusRule
+	phase int // 0 when the blocks start at the top left corner, 1 when they are shifted
+}
+
+// The grid must be bounded and even in both directions, so the blocks tile it
+func NewMargolusPartition(grid *Grid, rule *MargolusRule) (*MargolusPartition, error) {
+	if grid.Unbounded() {
+		return nil, errors.New("block rules need a bounded grid")
+	}
+	if grid.Width()%2 != 0 || grid.Height()%2 != 0 {
+		return nil, fmt.Errorf("block rules need an even width and height, got %dx%d", grid.Width(), grid.Height())
+	}
+
+	return &MargolusPartition{grid: grid, rule: rule}, nil
+}
+
+func (p *MargolusPartition) Grid() *Grid {
+	return p.grid
+}
+
+func (p *MargolusPartition) Rule() *MargolusRule {
+	return p.rule
+}
+
+// Returns 0 when the next step uses the blocks at the top left corner, and 1 when it uses the shifted ones
+func (p *MargolusPartition) Phase() int {
+	return p.phase
+}
+
+// Empties the grid and goes back to the blocks at the top left corner, so the same cells run the same way as before
+func (p *MargolusPartition) Restart() {
+	p.grid.Restart()
+	p.phase = 0
+}
+
+// Turns every block into the next one and shifts the blocks for the next step
+func (p *MargolusPartition) Step() {
+	for x := p.phase; x < p.grid.Width(); x += 2 {
+		for y := p.phase; y < p.grid.Height(); y += 2 {
+			if cells, ok := p.block(x, y); ok {
+				p.apply(cells)
+			}
+		}
+	}
+
+	p.phase = 1 - p.phase
+}
+
+// Returns the cells of the block with its top left corner at x, y, in the order of the bits of a block number
+// Blocks are skipped when a cell lies past an edge the boundary does not wrap, or when two cells wrap onto the same one
+func (p *MargolusPartition) block(x, y int) ([4]Point, bool) {
+	cells := [4]Point{{X: x, Y: y}, {X: x + 1, Y: y}, {X: x, Y: y + 1}, {X: x + 1, Y: y + 1}}
+	seen := make(map[Point]bool, len(cells))
+
+	for i, coords := range cells {
+		resolved, ok := p.grid.boundary.resolve(coords, p.grid.width, p.grid.height)
+		if !ok || seen[resolved] {
+			return cells, false
+		}
+
+		cells[i] = resolved
+		seen[resolved] = true
+	}
+
+	return cells, true
+}
+
+func (p *MargolusPartition) apply(cells [4]Point) {
+	var block int
+	for bit, coords := range cells {
+		if p.grid.Lookup(coords) != nil {
+			block |= 1 << bit
+		}
+	}
+
+	next := p.rule.Apply(block)
+	if next == block {
+		return
+	}
+
+	for bit, coords := range cells {
+		alive, wasAlive := next&(1<<bit) != 0, block&(1<<bit) != 0
+
+		switch {
+		case alive && !wasAlive:
+			NewDot(coords, p.grid)
+		case !alive && wasAlive:
+			p.grid.Remove(coords)
+		}
+	}
+}
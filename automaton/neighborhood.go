@@ -0,0 +1,273 @@
+package automaton
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Neighbourhoods that ParseNeighborhood knows by name, with the reach they get when none is given
+// A reach can be added to any of them, e.g. moore2 or checkerboard3
+var namedNeighborhoods = map[string]struct {
+	build func(reach int) *Neighborhood
+	reach int
+}{
+	"moore":        {MooreNeighborhood, 1},
+	"vonneumann":   {VonNeumannNeighborhood, 1},
+	"extended":     {VonNeumannNeighborhood, 2}, // Extended von Neumann
+	"cross":        {CrossNeighborhood, 2},
+	"checkerboard": {CheckerboardNeighborhood, 2},
+}
+
+//* -------------------------
+//* NEIGHBORHOOD
+//* -------------------------
+// The cells around a center cell that a rule counts, as offsets from the center
+// The center itself is never part of it
+type Neighborhood struct {
+	name    string // How ParseNeighborhood knows it, empty for masks
+	offsets []Point
+	reach   int // The furthest any offset goes along either axis
+}
+
+// Builds a neighbourhood of every offset within reach that include returns true for
+func newNeighborhood(name string, reach int, include func(x, y int) bool) *Neighborhood {
+	neighborhood := &Neighborhood{name: name, reach: reach}
+
+	for x := -reach; x <= reach; x++ {
+		for y := -reach; y <= reach; y++ {
+			if (x != 0 || y != 0) && include(x, y) {
+				neighborhood.offsets = append(neighborhood.offsets, Point{X: x, Y: y})
+			}
+		}
+	}
+
+	return neighborhood
+}
+
+// Every cell of the square around the center, the 8 neighbours of Conway's Game of Life for reach 1
+func MooreNeighborhood(reach int) *Neighborhood {
+	return newNeighborhood(reachName("moore", reach, 1), reach, func(x, y int) bool {
+		return true
+	})
+}
+
+// The diamond of cells at most reach steps away without going diagonally, the 4 orthogonal neighbours for reach 1
+// Reach 2 is known as the extended von Neumann neighbourhood
+func VonNeumannNeighborhood(reach int) *Neighborhood {
+	return newNeighborhood(reachName("vonneumann", reach, 1), reach, func(x, y int) bool {
+		return abs(x)+abs(y) <= reach
+	})
+}
+
+// The row and column through the center, out to reach on every side
+func CrossNeighborhood(reach int) *Neighborhood {
+	return newNeighborhood(reachName("cross", reach, 2), reach, func(x, y int) bool {
+		return x == 0 || y == 0
+	})
+}
+
+// Every other cell of the square around the center, the ones an odd number of orthogonal steps away
+func CheckerboardNeighborhood(reach int) *Neighborhood {
+	return newNeighborhood(reachName("checkerboard", reach, 2), reach, func(x, y int) bool {
+		return abs(x+y)%2 == 1
+	})
+}
+
+// The six neighbours on a hexagonal grid stored sheared into a square one (see HexagonalRule),
+// which are the Moore neighbours without the NE and SW corners
+func HexagonalNeighborhood() *Neighborhood {
+	return newNeighborhood("hexagonal", 1, func(x, y int) bool {
+		return x+y != 0 // NE is 1, -1 and SW is -1, 1
+	})
+}
+
+// Builds a neighbourhood from a mask indexed [y][x], where the center of the mask is the center cell
+// The mask must have an odd number of rows and columns, and the center entry is ignored
+func NewMaskNeighborhood(mask [][]bool) (*Neighborhood, error) {
+	height := len(mask)
+	if height%2 != 1 {
+		return nil, fmt.Errorf("a mask needs an odd number of rows, got %d", height)
+	}
+
+	width := len(mask[0])
+	for y, row := range mask {
+		if len(row) != width {
+			return nil, fmt.Errorf("row %d of the mask has %d cells instead of %d", y, len(row), width)
+		}
+	}
+	if width%2 != 1 {
+		return nil, fmt.Errorf("a mask needs an odd number of columns, got %d", width)
+	}
+
+	reachX, reachY := width/2, height/2
+	reach := reachX
+	if reachY > reach {
+		reach = reachY
+	}
+
+	neighborhood := newNeighborhood("", reach, func(x, y int) bool {
+		return abs(x) <= reachX && abs(y) <= reachY && mask[y+reachY][x+reachX]
+	})
+
+	if len(neighborhood.offsets) == 0 {
+		return nil, fmt.Errorf("the mask has no cells other than the center")
+	}
+
+	return neighborhood, nil
+}
+
+// Adds the reach to a name, unless it is the default one
+func reachName(name string, reach, defaultReach int) string {
+	if reach == defaultReach {
+		return name
+	}
+
+	return name + strconv.Itoa(reach)
+}
+
+func (n *Neighborhood) Offsets() []Point {
+	return n.offsets
+}
+
+// Returns how many cells to each side of the center the neighbourhood spans
+func (n *Neighborhood) Reach() int {
+	return n.reach
+}
+
+// Returns the size of the window that fits the neighbourhood
+func (n *Neighborhood) Size() int {
+	return n.reach*2 + 1
+}
+
+// Returns the number of cells in the neighbourhood, which is the highest count a rule can see
+func (n *Neighborhood) Len() int {
+	return len(n.offsets)
+}
+
+func (n *Neighborhood) Hexagonal() bool {
+	return n.name == "hexagonal"
+}
+
+// Returns the number of live cells of the neighbourhood in a window that is at least Size() big
+func (n *Neighborhood) Count(win *Window) int {
+	var count int
+	center := win.CenterIndex()
+
+	for _, offset := range n.offsets {
+		if win.Get(Point{X: center + offset.X, Y: center + offset.Y}) != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Returns the number of live cells of the neighbourhood around coords, straight from the grid
+func (n *Neighborhood) CountAt(grid *Grid, coords Point) int {
+	var count int
+
+	for _, offset := range n.offsets {
+		if grid.Lookup(Point{X: coords.X + offset.X, Y: coords.Y + offset.Y}) != nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Returns the name of the neighbourhood, or its mask with rows separated by slashes, as ParseNeighborhood reads it
+func (n *Neighborhood) String() string {
+	if n.name != "" {
+		return n.name
+	}
+
+	included := make(map[Point]bool, len(n.offsets))
+	for _, offset := range n.offsets {
+		included[offset] = true
+	}
+
+	rows := make([]string, 0, n.Size())
+	for y := -n.reach; y <= n.reach; y++ {
+		var sb strings.Builder
+
+		for x := -n.reach; x <= n.reach; x++ {
+			switch {
+			case x == 0 && y == 0:
+				sb.WriteByte('o')
+			case included[Point{X: x, Y: y}]:
+				sb.WriteByte('#')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+
+		rows = append(rows, sb.String())
+	}
+
+	return strings.Join(rows, "/")
+}
+
+// Parses a neighbourhood by name (moore, vonneumann, extended, cross, checkerboard or hexagonal, optionally followed by a reach like moore2),
+// or as a mask with one row per line (or separated by slashes), where # or 1 is part of the neighbourhood and . or 0 is not
+// The center of the mask is the center cell, which can be written as anything
+func ParseNeighborhood(spec string) (*Neighborhood, error) {
+	spec = strings.TrimSpace(spec)
+	lower := strings.ToLower(spec)
+
+	if lower == "hexagonal" {
+		return HexagonalNeighborhood(), nil
+	}
+
+	name := strings.TrimRight(lower, "0123456789")
+	if named, ok := namedNeighborhoods[name]; ok {
+		reach := named.reach
+
+		if digits := lower[len(name):]; digits != "" {
+			reach, _ = strconv.Atoi(digits)
+			if !between(reach, 1, MAX_LTL_RADIUS) {
+				return nil, fmt.Errorf("invalid neighbourhood %q: reach %d is not between 1 and %d", spec, reach, MAX_LTL_RADIUS)
+			}
+		}
+
+		return named.build(reach), nil
+	}
+
+	var lines []string
+	for _, line := range strings.FieldsFunc(spec, func(char rune) bool { return char == '\n' || char == '/' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	var mask [][]bool
+
+	for y, line := range lines {
+		row := make([]bool, 0, len(line))
+		for x, char := range line {
+			switch {
+			case len(lines)/2 == y && len(line)/2 == x:
+				row = append(row, false)
+			case char == '#' || char == '1':
+				row = append(row, true)
+			case char == '.' || char == '0':
+				row = append(row, false)
+			default:
+				return nil, fmt.Errorf("invalid neighbourhood %q: %q is not a neighbourhood name or a mask of # and . (or 1 and 0)", spec, char)
+			}
+		}
+
+		mask = append(mask, row)
+	}
+
+	if len(mask) == 0 {
+		return nil, fmt.Errorf("invalid neighbourhood %q: empty mask", spec)
+	}
+
+	neighborhood, err := NewMaskNeighborhood(mask)
+	if err != nil {
+		return nil, fmt.Errorf("invalid neighbourhood %q: %v", spec, err)
+	}
+
+	return neighborhood, nil
+}
@@ -0,0 +1,114 @@
+package automaton
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/icza/gox/imagex/colorx"
+)
+
+//* -------------------------
+//* PALETTE
+//* -------------------------
+// The states of a multi-state rule that cells can be painted with, in the order they are picked
+type Palette []PaletteEntry
+
+type PaletteEntry struct {
+	State int
+	Name  string
+	Fill  color.Color
+}
+
+// Returns the fill of a state, and whether the palette has it
+func (p Palette) Fill(state int) (color.Color, bool) {
+	for _, entry := range p {
+		if entry.State == state {
+			return entry.Fill, true
+		}
+	}
+
+	return nil, false
+}
+
+// Returns the index of the entry after the one with the given state, wrapping around
+// States that are not in the palette start over at the first entry
+func (p Palette) Next(state int) int {
+	for i, entry := range p {
+		if entry.State == state {
+			return (i + 1) % len(p)
+		}
+	}
+
+	return 0
+}
+
+// Fails if the palette has a state that is not in states, which is usually the palette of the rule it is meant for
+func (p Palette) CheckStates(states Palette) error {
+	for _, entry := range p {
+		if _, ok := states.Fill(entry.State); !ok {
+			return fmt.Errorf("the rule has no state %d (%s)", entry.State, entry.Name)
+		}
+	}
+
+	return nil
+}
+
+// Returns the palette as text that ParsePalette reads back, one entry per line like "2 head #4dabf7"
+func (p Palette) String() string {
+	var sb strings.Builder
+
+	for _, entry := range p {
+		r, g, b, _ := entry.Fill.RGBA()
+		sb.WriteString(fmt.Sprintf("%d %s #%02x%02x%02x\n", entry.State, entry.Name, r>>8, g>>8, b>>8))
+	}
+
+	return sb.String()
+}
+
+// Parses a palette written like Palette.String, empty lines and lines starting with # are skipped
+// Names can not contain spaces
+func ParsePalette(text string) (Palette, error) {
+	var palette Palette
+
+	for number, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected a state, a name and a colour, got %q", number+1, line)
+		}
+
+		state, err := strconv.Atoi(fields[0])
+		if err != nil || state < 1 {
+			return nil, fmt.Errorf("line %d: state %q is not a number above 0", number+1, fields[0])
+		}
+
+		fill, err := colorx.ParseHexColor(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number+1, err)
+		}
+
+		palette = append(palette, PaletteEntry{State: state, Name: fields[1], Fill: fill})
+	}
+
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("palette has no states")
+	}
+
+	return palette, nil
+}
+
+// Palette of a rule whose states are alive and then dying, like GenerationsRule
+func dyingPalette(fills []color.Color) Palette {
+	palette := Palette{{State: 1, Name: "alive", Fill: fills[1]}}
+	for state := 2; state < len(fills); state++ {
+		palette = append(palette, PaletteEntry{State: state, Name: fmt.Sprintf("dying%d", state-1), Fill: fills[state]})
+	}
+
+	return palette
+}
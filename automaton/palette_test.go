@@ -0,0 +1,24 @@
+package automaton
+
+import "testing"
+
+func TestPaletteCheckStates(t *testing.T) {
+	wireworld, err := ParsePalette(NewWireworld().Palette().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wireworld.CheckStates(NewWireworld().Palette()); err != nil {
+		t.Fatalf("a saved palette should fit its own rule: %v", err)
+	}
+
+	// Brian's Brain has states 1 and 2, but no 3
+	brain := mustParseRule(t, "B2/S/C3").(MultiStateRule)
+	if err := wireworld.CheckStates(brain.Palette()); err == nil {
+		t.Fatal("expected a wireworld palette to be rejected for a rule with fewer states")
+	}
+
+	if err := wireworld[:1].CheckStates(brain.Palette()); err != nil {
+		t.Fatalf("a palette with some of the rule's states should fit: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package automaton
+
+import "math/rand"
+
+const (
+	DEFAULT_SEED = 1 // Like math/rand, grids start out seeded with 1
+)
+
+//* -------------------------
+//* RANDOMNESS
+//* -------------------------
+// Every grid has its own seed, so the same seed and the same starting cells give the same run
+// Rules draw per-cell values that only depend on the seed, the generation and the cell's coords,
+// so the workers of a convolution get the same values no matter which of them visits which cell
+
+func (g *Grid) Seed() int64 {
+	return g.seed
+}
+
+// Starts the grid's randomness over from a seed
+func (g *Grid) SetSeed(seed int64) {
+	g.seed = seed
+	g.rng = rand.New(rand.NewSource(seed))
+	g.generation = 0
+}
+
+// Empties the grid and starts its randomness over from its seed, so the same cells run the same way as before
+func (g *Grid) Restart() {
+	g.Clear()
+	g.SetSeed(g.seed)
+}
+
+// Returns the grid's own random source, for code that draws values one after another (never from convolution workers)
+func (g *Grid) Rand() *rand.Rand {
+	return g.rng
+}
+
+// Like Grid.Seed, for continuous grids
+func (g *ContinuousGrid) Seed() int64 {
+	return g.seed
+}
+
+func (g *ContinuousGrid) SetSeed(seed int64) {
+	g.seed = seed
+	g.rng = rand.New(rand.NewSource(seed))
+}
+
+// Empties the grid and starts its randomness over from its seed
+func (g *ContinuousGrid) Restart() {
+	g.Clear()
+	g.SetSeed(g.seed)
+}
+
+func (g *ContinuousGrid) Rand() *rand.Rand {
+	return g.rng
+}
+
+// Returns a random number in [0, 1) for a cell in the current generation
+// Rules that need more than one number per cell ask for draw 0, 1, 2 and so on
+func (g *Grid) CellRandom(coords Point, draw int) float64 {
+	hash := uint64(g.seed)
+	for _, value := range [4]uint64{g.generation, uint64(coords.X), uint64(coords.Y), uint64(draw)} {
+		hash = splitMix64(hash ^ value)
+	}
+
+	// The top 53 bits fill the mantissa of a float64
+	return float64(hash>>11) / (1 << 53)
+}
+
+// Returns a random number in [0, 1) for the center cell of the window, see Grid.CellRandom
+func (w *Window) Random(draw int) float64 {
+	return w.grid.CellRandom(w.center, draw)
+}
+
+// Scrambles a number so that close inputs give unrelated outputs (the finalizer of SplitMix64)
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+
+	return x ^ x>>31
+}
@@ -0,0 +1,139 @@
+package automaton
+
+import (
+	"fmt"
+	"testing"
+)
+
+// The same seed and the same starting cells must give the same run, also after restarting the grid
+func TestRestartRepeatsSeededRun(t *testing.T) {
+	conv := mustParseRule(t, "B3/S23:birth=0.8,noise=0.02")
+
+	run := func(grid *Grid) *Grid {
+		randomSoup(grid, 5, 0.3)
+		for i := 0; i < 10; i++ {
+			grid.Convolve(conv)
+		}
+
+		return grid
+	}
+
+	first := NewGrid(20, 20)
+	first.SetSeed(0)
+	run(first)
+
+	again := NewGrid(20, 20)
+	again.SetSeed(0)
+	run(again)
+	compareGrids(t, 10, first, again)
+
+	// Restarting has to wind the generation and the random source back as well
+	again.Restart()
+	if again.Seed() != 0 || again.NumUsedCells() != 0 {
+		t.Fatalf("restarted grid has seed %d and %d cells, expected seed 0 and no cells", again.Seed(), again.NumUsedCells())
+	}
+	run(again)
+	compareGrids(t, 10, first, again)
+
+	if first.Rand().Int63() != again.Rand().Int63() {
+		t.Fatal("restarted grid draws other values from its random source")
+	}
+}
+
+func TestCellRandomDependsOnSeed(t *testing.T) {
+	grid, other := NewGrid(4, 4), NewGrid(4, 4)
+	other.SetSeed(2)
+
+	coords := Point{X: 1, Y: 2}
+	if grid.CellRandom(coords, 0) != grid.CellRandom(coords, 0) {
+		t.Fatal("the same cell and draw should give the same value")
+	}
+	if grid.CellRandom(coords, 0) == other.CellRandom(coords, 0) {
+		t.Fatal("other seeds should give other values")
+	}
+	if grid.CellRandom(coords, 0) == grid.CellRandom(coords, 1) {
+		t.Fatal("other draws should give other values")
+	}
+}
+
+// Runs twice with a restart in between, painting the same cells both times, and fails if the runs differ
+func compareRestartedRuns(t *testing.T, grid *Grid, paint func(), step func(), restart func()) {
+	t.Helper()
+
+	paint()
+	for i := 0; i < 5; i++ {
+		step()
+	}
+	first := cellStates(grid)
+
+	restart()
+	paint()
+	for i := 0; i < 5; i++ {
+		step()
+	}
+
+	again := cellStates(grid)
+	for x := range first {
+		for y := range first[x] {
+			if first[x][y] != again[x][y] {
+				t.Fatalf("cell %v is in state %d after restarting, expected %d", Point{X: x, Y: y}, again[x][y], first[x][y])
+			}
+		}
+	}
+}
+
+// An odd number of steps leaves the blocks shifted, which restarting has to undo
+func TestMargolusRestartRepeatsRun(t *testing.T) {
+	rule, err := ParseMargolusRule("critters")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partition, err := NewMargolusPartition(NewGrid(16, 16), rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paint := func() { randomSoup(partition.Grid(), 6, 0.3) }
+	compareRestartedRuns(t, partition.Grid(), paint, partition.Step, func() {
+		partition.Restart()
+		if partition.Phase() != 0 {
+			t.Fatalf("phase is %d after restarting, expected 0", partition.Phase())
+		}
+	})
+}
+
+// An odd number of ticks leaves the rows scanned from the other side, which restarting has to undo
+func TestSandboxRestartRepeatsRun(t *testing.T) {
+	sandbox := newTestSandbox(t, 12, 12)
+
+	paint := func() {
+		for x := 2; x < 10; x++ {
+			sandbox.Paint(Point{X: x, Y: 2}, MATERIAL_SAND)
+			sandbox.Paint(Point{X: x, Y: 4}, MATERIAL_WATER)
+			sandbox.Paint(Point{X: x, Y: 8}, MATERIAL_FIRE)
+		}
+	}
+	compareRestartedRuns(t, sandbox.Grid(), paint, sandbox.Step, sandbox.Restart)
+}
+
+func TestContinuousRestartRepeatsRandomValues(t *testing.T) {
+	grid := NewContinuousGrid(8, 8)
+	grid.SetSeed(3)
+
+	draw := func() []float64 {
+		values := make([]float64, 5)
+		for i := range values {
+			values[i] = grid.Rand().Float64()
+		}
+
+		return values
+	}
+
+	first := draw()
+	grid.Restart()
+
+	if again := draw(); fmt.Sprint(again) != fmt.Sprint(first) {
+		t.Fatalf("drew %v after restarting, expected %v", again, first)
+	}
+}
@@ -1,10 +1,56 @@
-package main
+package automaton
 
 type Convolver interface {
 	ApplyKernel(*Window) *Dot
 	Size() int
 }
 
+// Rules that can read a cell's neighbourhood straight from the grid, so no Window has to be built for every cell
+// Grid.Convolve prefers ApplyCell over ApplyKernel, and both must give the same result
+type CellConvolver interface {
+	Convolver
+	ApplyCell(grid *Grid, coords Point) *Dot
+}
+
+// Rules that need to look at the whole grid before a convolution, e.g. to build running sums for big windows
+// Grid.Convolve calls Prepare once before any cell is visited, and the rule reads back what it returned with Grid.Prepared
+// Nothing is kept on the rule itself, so one rule can convolve several grids at once
+type PreparedConvolver interface {
+	Convolver
+	Prepare(grid *Grid) interface{}
+}
+
+// Rules with more states than alive or dead, which cells can be painted with
+type MultiStateRule interface {
+	Convolver
+	Palette() Palette
+}
+
+// Rules for a hexagonal grid, which is stored sheared into a square one:
+// the six neighbours of a cell are its Moore neighbours without the NE and SW corners (see HexagonalNeighborhood)
+type HexagonalRule interface {
+	Convolver
+	Hexagonal() bool
+}
+
+// Whether the rule runs on a hexagonal grid, so its cells should be drawn (and clicked) as hexagons
+func IsHexagonal(conv Convolver) bool {
+	hex, ok := conv.(HexagonalRule)
+	return ok && hex.Hexagonal()
+}
+
+// Rules that also leave things to chance, so a cell can change even when nothing around it did
+// Grid.Convolve visits every cell for them, since active tracking can not tell which cells might change
+type StochasticRule interface {
+	Convolver
+	Stochastic() bool
+}
+
+func IsStochastic(conv Convolver) bool {
+	stochastic, ok := conv.(StochasticRule)
+	return ok && stochastic.Stochastic()
+}
+
 type Kernel struct {
 	size int
 }
@@ -0,0 +1,342 @@
+package automaton
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Letters after a Life-like rule that pick its neighbourhood, like Golly's
+var neighborhoodSuffixes = map[string]func() *Neighborhood{
+	"H": HexagonalNeighborhood,
+	"V": func() *Neighborhood { return VonNeumannNeighborhood(1) },
+}
+
+// Well known rules, which ParseRule accepts by name (case insensitive)
+var NamedRules = map[string]string{
+	"life":               "B3/S23",
+	"highlife":           "B36/S23",
+	"day & night":        "B3678/S34678",
+	"seeds":              "B2/S",
+	"life without death": "B3/S012345678",
+	"maze":               "B3/S12345",
+	"replicator":         "B1357/S1357",
+	"2x2":                "B36/S125",
+	"diamoeba":           "B35678/S5678",
+	"morley":             "B368/S245",
+	"anneal":             "B4678/S35678",
+	"hexlife":            "B2/S34H",
+	"brian's brain":      "B2/S/C3",
+	"star wars":          "B2/S345/C4",
+	"frogs":              "B34/S12/C3",
+	"bosco":              "R5,C0,M1,S34..58,B34..45,NM",
+	"majority":           "R4,C0,M1,S41..81,B41..81,NM",
+	"waffle":             "R7,C0,M1,S100..200,B75..170,NM",
+	"globe":              "R8,C0,M0,S163..223,B74..252,NM",
+}
+
+// Parses a rule string into a Convolver
+// Life-like rules can be written as B36/S23 (in any case, and in any order), as S/B like 23/36, or by one of the NamedRules
+// Isotropic non-totalistic rules are written in Hensel notation, like B2-a/S12
+// Generations rules add a number of states, like B2/S/C3 or /2/3
+// Larger than Life rules are written like R5,C0,M1,S34..58,B34..45,NM
+// Wireworld is only known by name
+// Any 2-state rule can be followed by chances, like B3/S23:birth=0.9,survival=0.99,noise=0.001 (see ProbabilisticRule)
+// Life-like rules ending in H, like B2/S34H, run on a hexagonal grid, and ones ending in V, like B1/S1V, count the von Neumann neighbourhood
+func ParseRule(rule string) (Convolver, error) {
+	rule = strings.TrimSpace(rule)
+
+	// B3/S23:birth=0.9,noise=0.001
+	if colon := strings.IndexByte(rule, ':'); colon >= 0 {
+		base, err := ParseRule(rule[:colon])
+		if err != nil {
+			return nil, err
+		}
+
+		probabilistic, err := parseProbabilistic(base, strings.ReplaceAll(rule[colon+1:], " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+		}
+
+		return probabilistic, nil
+	}
+
+	if strings.EqualFold(rule, "wireworld") {
+		return NewWireworld(), nil
+	}
+
+	if named, ok := NamedRules[strings.ToLower(rule)]; ok {
+		rule = named
+	}
+
+	if strings.HasPrefix(strings.ToUpper(rule), "R") && strings.Contains(rule, ",") {
+		ltl, err := parseLargerThanLife(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		return ltl, nil
+	}
+
+	// B2/S34H or B1/S1V
+	for suffix, neighborhood := range neighborhoodSuffixes {
+		if !strings.HasSuffix(strings.ToUpper(rule), suffix) {
+			continue
+		}
+
+		birth, survival, err := splitBirthSurvival(strings.TrimSuffix(strings.ToUpper(rule), suffix))
+		if err != nil {
+			return nil, err
+		}
+
+		lifeLike, err := parseLifeLike(rule, birth, survival, neighborhood())
+		if err != nil {
+			return nil, err
+		}
+
+		return lifeLike, nil
+	}
+
+	binary, states, err := splitStates(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	birth, survival, err := splitBirthSurvival(binary)
+	if err != nil {
+		return nil, err
+	}
+
+	if states != 2 {
+		generations, err := NewGenerationsRule(birth, survival, states)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+		}
+
+		return generations, nil
+	}
+
+	// Only isotropic rules have shape letters
+	if strings.ContainsAny(birth+survival, HENSEL_LETTERS+"-") {
+		isotropic, err := NewIsotropicRule(birth, survival)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+		}
+
+		return isotropic, nil
+	}
+
+	lifeLike, err := parseLifeLike(rule, birth, survival, MooreNeighborhood(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return lifeLike, nil
+}
+
+//* -------------------------
+//* LIFE-LIKE RULE
+//* -------------------------
+// Outer-totalistic rule, where a dead cell is born and a live cell survives depending only on its number of live neighbours
+// Neighbours are counted over a Neighborhood, the Moore neighbourhood unless said otherwise
+type LifeLikeRule struct {
+	Kernel
+	birth, survival []bool // Indexed by number of live neighbours, up to the size of the neighbourhood
+	neighborhood    *Neighborhood
+}
+
+func NewLifeLikeRule(birth, survival []int) (*LifeLikeRule, error) {
+	return NewNeighborhoodRule(birth, survival, MooreNeighborhood(1))
+}
+
+// Like NewLifeLikeRule, but for a hexagonal grid where counts go up to 6
+func NewHexagonalLifeLikeRule(birth, survival []int) (*LifeLikeRule, error) {
+	return NewNeighborhoodRule(birth, survival, HexagonalNeighborhood())
+}
+
+// Like NewLifeLikeRule, but counting over any neighbourhood
+// Counts go up to the number of cells in it, but no higher than 9, which is the last one B/S notation can write
+func NewNeighborhoodRule(birth, survival []int, neighborhood *Neighborhood) (*LifeLikeRule, error) {
+	highest := neighborhood.Len()
+	if highest > 9 {
+		highest = 9
+	}
+
+	rule := &LifeLikeRule{
+		Kernel:       Kernel{size: neighborhood.Size()},
+		birth:        make([]bool, neighborhood.Len()+1),
+		survival:     make([]bool, neighborhood.Len()+1),
+		neighborhood: neighborhood,
+	}
+
+	for _, count := range birth {
+		if !between(count, 0, highest) {
+			return nil, fmt.Errorf("birth count %d is not between 0 and %d in the %v neighbourhood", count, highest, neighborhood)
+		}
+
+		rule.birth[count] = true
+	}
+
+	for _, count := range survival {
+		if !between(count, 0, highest) {
+			return nil, fmt.Errorf("survival count %d is not between 0 and %d in the %v neighbourhood", count, highest, neighborhood)
+		}
+
+		rule.survival[count] = true
+	}
+
+	return rule, nil
+}
+
+func (r *LifeLikeRule) ApplyKernel(win *Window) *Dot {
+	return r.next(win.Center(), win.GridCoords(), r.neighborhood.Count(win))
+}
+
+// Counts straight from the grid, which saves building a window for big neighbourhoods
+func (r *LifeLikeRule) ApplyCell(grid *Grid, coords Point) *Dot {
+	return r.next(grid.Lookup(coords), coords, r.neighborhood.CountAt(grid, coords))
+}
+
+// Returns the next value of a cell, given its number of live neighbours
+func (r *LifeLikeRule) next(center *Dot, coords Point, alive int) *Dot {
+	if center != nil {
+		if r.survival[alive] {
+			return center
+		}
+
+		return nil
+	}
+
+	if r.birth[alive] {
+		return NewDot(coords, nil)
+	}
+
+	return nil
+}
+
+func (r *LifeLikeRule) Size() int {
+	return r.size
+}
+
+func (r *LifeLikeRule) Neighborhood() *Neighborhood {
+	return r.neighborhood
+}
+
+// Returns the same birth and survival counts, counted over another neighbourhood
+func (r *LifeLikeRule) WithNeighborhood(neighborhood *Neighborhood) (*LifeLikeRule, error) {
+	return NewNeighborhoodRule(setCounts(r.birth), setCounts(r.survival), neighborhood)
+}
+
+func (r *LifeLikeRule) Hexagonal() bool {
+	return r.neighborhood.Hexagonal()
+}
+
+// Returns the rule in B/S notation, e.g. B3/S23, B2/S34H for hexagonal rules and B1/S1V for von Neumann rules
+// Other neighbourhoods are named after the rule, e.g. B3/S23 (checkerboard)
+func (r *LifeLikeRule) String() string {
+	rule := fmt.Sprintf("B%s/S%s", countDigits(r.birth), countDigits(r.survival))
+	if r.neighborhood.String() == "moore" {
+		return rule
+	}
+
+	for suffix, neighborhood := range neighborhoodSuffixes {
+		if neighborhood().String() == r.neighborhood.String() {
+			return rule + suffix
+		}
+	}
+
+	return fmt.Sprintf("%s (%v)", rule, r.neighborhood)
+}
+
+// Returns the birth and survival parts of a rule, e.g. "36" and "23" for B36/S23 or 23/36
+// Letters other than B and S are returned in lowercase, for Hensel notation
+func splitBirthSurvival(rule string) (birth, survival string, err error) {
+	compact := strings.ToLower(strings.ReplaceAll(rule, " ", ""))
+
+	if !strings.ContainsAny(compact, "bs") {
+		// 23/3 is survival first
+		parts := strings.Split(compact, "/")
+		if len(parts) != 2 {
+			return "", "", fmt.Errorf("invalid rule %q: expected B/S or S/B notation", rule)
+		}
+
+		return parts[1], parts[0], nil
+	}
+
+	// B3/S23, S23/B3, B3S23 or any of those in lowercase
+	var current *string
+	for _, char := range compact {
+		switch {
+		case char == 'b':
+			current = &birth
+		case char == 's':
+			current = &survival
+		case char == '/':
+			current = nil
+		case current != nil:
+			*current += string(char)
+		default:
+			return "", "", fmt.Errorf("invalid rule %q: %q is not part of a B or S section", rule, char)
+		}
+	}
+
+	return birth, survival, nil
+}
+
+func parseLifeLike(rule, birth, survival string, neighborhood *Neighborhood) (*LifeLikeRule, error) {
+	birthCounts, err := parseCounts(birth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+	}
+
+	survivalCounts, err := parseCounts(survival)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+	}
+
+	lifeLike, err := NewNeighborhoodRule(birthCounts, survivalCounts, neighborhood)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %v", rule, err)
+	}
+
+	return lifeLike, nil
+}
+
+// Parses neighbour counts written as a string of digits, e.g. "236"
+func parseCounts(digits string) ([]int, error) {
+	var counts []int
+
+	for _, digit := range digits {
+		if digit < '0' || digit > '9' {
+			return nil, fmt.Errorf("%q is not a neighbour count between 0 and 9", digit)
+		}
+
+		counts = append(counts, int(digit-'0'))
+	}
+
+	return counts, nil
+}
+
+// Returns the indexes that are set
+func setCounts(counts []bool) []int {
+	var set []int
+	for count, ok := range counts {
+		if ok {
+			set = append(set, count)
+		}
+	}
+
+	return set
+}
+
+// Returns the indexes that are set, as a string of digits
+func countDigits(counts []bool) string {
+	var sb strings.Builder
+
+	for count, set := range counts {
+		if set {
+			sb.WriteByte(byte('0' + count))
+		}
+	}
+
+	return sb.String()
+}
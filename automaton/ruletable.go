@@ -0,0 +1,412 @@
+package automaton
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The cells a Golly table reads in its own order: the center first, then the neighbours clockwise from north
+// Each ring of neighbours is in rotational order, which the symmetries rely on
+var tableOffsets = map[string][]Point{
+	"moore": {
+		{X: 0, Y: 0},
+		{X: 0, Y: -1}, {X: 1, Y: -1}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}, {X: -1, Y: 1}, {X: -1, Y: 0}, {X: -1, Y: -1},
+	},
+	"vonneumann": {
+		{X: 0, Y: 0},
+		{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0},
+	},
+	// N, E, SE, S, W and NW, which are the neighbours of HexagonalNeighborhood
+	"hexagonal": {
+		{X: 0, Y: 0},
+		{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}, {X: -1, Y: 0}, {X: -1, Y: -1},
+	},
+}
+
+// Set of states, one bit per state
+type stateSet [MAX_GOLLY_STATES / 64]uint64
+
+func (s *stateSet) add(state int) {
+	s[state/64] |= 1 << (state % 64)
+}
+
+func (s stateSet) has(state int) bool {
+	return s[state/64]&(1<<(state%64)) != 0
+}
+
+// Orders sets by their words, so the arrangements of permute symmetry can be listed without repeats
+func (s stateSet) less(other stateSet) bool {
+	for i := range s {
+		if s[i] != other[i] {
+			return s[i] < other[i]
+		}
+	}
+
+	return false
+}
+
+//* -------------------------
+//* RULE TABLE
+//* -------------------------
+// Transitions of a Golly @TABLE, tried in order until one matches
+// Every input position has a bit set of the transitions that accept each state,
+// so matching a cell is ANDing one set per position and taking the lowest bit
+type ruleTable struct {
+	accepts [][][]uint64 // Indexed [position][state][word]
+	outputs []int
+}
+
+type tableTransition struct {
+	inputs []stateSet // The center, then the neighbours
+	output int
+}
+
+func (t *ruleTable) next(cells []int) (int, bool) {
+	words := (len(t.outputs) + 63) / 64
+
+	for word := 0; word < words; word++ {
+		matches := ^uint64(0)
+
+		for position, state := range cells {
+			if state >= len(t.accepts[position]) {
+				return 0, false
+			}
+
+			if matches &= t.accepts[position][state][word]; matches == 0 {
+				break
+			}
+		}
+
+		if matches != 0 {
+			return t.outputs[word*64+bits.TrailingZeros64(matches)], true
+		}
+	}
+
+	return 0, false
+}
+
+// Parses the body of a @TABLE section, or a bare .table file:
+// n_states, neighborhood and symmetries settings, var lines like "var a={0,1,2}", and transitions like 0,1,a,a,0,0,0,0,0,1
+// (the center, the neighbours clockwise from north, then the new state), which can be written without commas for single digit states
+// A variable that appears more than once in a transition, or as its new state, has the same value everywhere in it
+func parseRuleTable(text string) (*GollyRule, error) {
+	states, neighborhood, symmetries := 0, "moore", "none"
+	variables := make(map[string]stateSet)
+	var transitions []tableTransition
+
+	for _, line := range gollyLines(text) {
+		// Golly files are written with spaces and tabs alike
+		compact := strings.Join(strings.Fields(line), "")
+
+		if colon := strings.IndexByte(compact, ':'); colon >= 0 && !strings.HasPrefix(compact, "var") {
+			key, value := strings.ToLower(compact[:colon]), compact[colon+1:]
+
+			switch key {
+			case "n_states", "num_states":
+				var err error
+				if states, err = strconv.Atoi(value); err != nil || !between(states, 2, MAX_GOLLY_STATES) {
+					return nil, fmt.Errorf("n_states must be a number between 2 and %d, got %q", MAX_GOLLY_STATES, value)
+				}
+			case "neighborhood", "neighbourhood":
+				neighborhood = strings.ToLower(value)
+				if _, ok := tableOffsets[neighborhood]; !ok {
+					return nil, fmt.Errorf("unsupported neighbourhood %q, expected Moore, vonNeumann or hexagonal", value)
+				}
+			case "symmetries":
+				symmetries = strings.ToLower(value)
+			default:
+				return nil, fmt.Errorf("unknown setting %q", key)
+			}
+			continue
+		}
+
+		if states == 0 {
+			return nil, fmt.Errorf("n_states must come before %q", line)
+		}
+
+		if strings.HasPrefix(compact, "var") {
+			name, set, err := parseTableVariable(compact[len("var"):], states, variables)
+			if err != nil {
+				return nil, fmt.Errorf("invalid variable %q: %v", line, err)
+			}
+
+			variables[name] = set
+			continue
+		}
+
+		permutations, err := tableSymmetries(symmetries, len(tableOffsets[neighborhood])-1)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded, err := parseTableTransition(line, states, len(tableOffsets[neighborhood])+1, variables, permutations)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transition %q: %v", line, err)
+		}
+
+		transitions = append(transitions, expanded...)
+	}
+
+	if states == 0 {
+		return nil, fmt.Errorf("the table has no n_states")
+	}
+
+	table := &ruleTable{accepts: make([][][]uint64, len(tableOffsets[neighborhood])), outputs: make([]int, len(transitions))}
+	words := (len(transitions) + 63) / 64
+
+	for position := range table.accepts {
+		table.accepts[position] = make([][]uint64, states)
+		for state := range table.accepts[position] {
+			table.accepts[position][state] = make([]uint64, words)
+		}
+	}
+
+	for i, transition := range transitions {
+		table.outputs[i] = transition.output
+
+		for position, set := range transition.inputs {
+			for state := 0; state < states; state++ {
+				if set.has(state) {
+					table.accepts[position][state][i/64] |= 1 << (i % 64)
+				}
+			}
+		}
+	}
+
+	return newGollyRule(states, neighborhood, tableOffsets[neighborhood], table), nil
+}
+
+// Parses what follows "var", like a={0,1,2}, b={a,3} or c=a
+func parseTableVariable(definition string, states int, variables map[string]stateSet) (string, stateSet, error) {
+	var set stateSet
+
+	equals := strings.IndexByte(definition, '=')
+	if equals < 1 {
+		return "", set, fmt.Errorf("expected a name and values like a={0,1}")
+	}
+
+	name, values := definition[:equals], strings.Trim(definition[equals+1:], "{}")
+
+	for _, value := range strings.Split(values, ",") {
+		values, err := tableValues(value, states, variables)
+		if err != nil {
+			return "", set, err
+		}
+
+		for i := range set {
+			set[i] |= values[i]
+		}
+	}
+
+	return name, set, nil
+}
+
+// Returns the states of a single state or a variable
+func tableValues(token string, states int, variables map[string]stateSet) (stateSet, error) {
+	if set, ok := variables[token]; ok {
+		return set, nil
+	}
+
+	var set stateSet
+
+	state, err := strconv.Atoi(token)
+	if err != nil {
+		return set, fmt.Errorf("%q is not a state or a variable", token)
+	}
+	if !between(state, 0, states-1) {
+		return set, fmt.Errorf("state %d is not between 0 and %d", state, states-1)
+	}
+
+	set.add(state)
+
+	return set, nil
+}
+
+// Turns a transition into one for every value of its bound variables, and every arrangement of its neighbours the symmetries allow
+// Entries are separated by commas, by whitespace, or not at all when every entry is a single character
+func parseTableTransition(line string, states, entries int, variables map[string]stateSet, permutations func([]stateSet) [][]stateSet) ([]tableTransition, error) {
+	var tokens []string
+	switch fields := strings.Fields(line); {
+	case strings.Contains(line, ","):
+		tokens = strings.Split(strings.Join(fields, ""), ",")
+	case len(fields) > 1:
+		tokens = fields
+	default:
+		tokens = strings.Split(line, "")
+	}
+
+	if len(tokens) != entries {
+		return nil, fmt.Errorf("expected %d entries, got %d", entries, len(tokens))
+	}
+
+	// Variables that appear more than once, or that are the new state, are bound, the rest match any of their values
+	uses := make(map[string]int)
+	for _, token := range tokens[:len(tokens)-1] {
+		if _, ok := variables[token]; ok {
+			uses[token]++
+		}
+	}
+
+	output := tokens[len(tokens)-1]
+	if _, ok := variables[output]; ok && uses[output] == 0 {
+		return nil, fmt.Errorf("the new state %q is a variable that is not used in the inputs", output)
+	}
+
+	var bound []string
+	for name, count := range uses {
+		if count > 1 || name == output {
+			bound = append(bound, name)
+		}
+	}
+	sort.Strings(bound)
+
+	var transitions []tableTransition
+	values := make(map[string]int)
+
+	var expand func(i int) error
+	expand = func(i int) error {
+		if i < len(bound) {
+			set := variables[bound[i]]
+			for state := 0; state < states; state++ {
+				if set.has(state) {
+					values[bound[i]] = state
+					if err := expand(i + 1); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		}
+
+		inputs := make([]stateSet, len(tokens)-1)
+		for position, token := range tokens[:len(tokens)-1] {
+			if state, ok := values[token]; ok {
+				inputs[position].add(state)
+				continue
+			}
+
+			set, err := tableValues(token, states, variables)
+			if err != nil {
+				return err
+			}
+
+			inputs[position] = set
+		}
+
+		next, ok := values[output]
+		if !ok {
+			var err error
+			if next, err = strconv.Atoi(output); err != nil || !between(next, 0, states-1) {
+				return fmt.Errorf("the new state %q is not a state between 0 and %d", output, states-1)
+			}
+		}
+
+		for _, arrangement := range permutations(inputs[1:]) {
+			transitions = append(transitions, tableTransition{inputs: append([]stateSet{inputs[0]}, arrangement...), output: next})
+		}
+
+		return nil
+	}
+
+	if err := expand(0); err != nil {
+		return nil, err
+	}
+
+	return transitions, nil
+}
+
+// Returns a function that lists every arrangement of the neighbour inputs a symmetry allows, without repeats
+// neighbours is the size of the ring of neighbours, whose positions are in rotational order
+func tableSymmetries(symmetries string, neighbours int) (func([]stateSet) [][]stateSet, error) {
+	if symmetries == "permute" {
+		return permuteInputs, nil
+	}
+
+	// Rotations are steps around the ring, the reflection mirrors it around north
+	var rotations int
+	reflect := false
+
+	switch {
+	case symmetries == "none":
+		rotations = 1
+	case symmetries == "reflect" || symmetries == "reflect_horizontal":
+		rotations, reflect = 1, true
+	case strings.HasPrefix(symmetries, "rotate"):
+		number := strings.TrimSuffix(strings.TrimPrefix(symmetries, "rotate"), "reflect")
+		reflect = strings.HasSuffix(symmetries, "reflect")
+
+		var err error
+		if rotations, err = strconv.Atoi(number); err != nil || rotations < 1 || neighbours%rotations != 0 {
+			return nil, fmt.Errorf("symmetry %q does not fit %d neighbours", symmetries, neighbours)
+		}
+	default:
+		return nil, fmt.Errorf("unknown symmetry %q, expected none, rotate4, rotate8, reflect_horizontal, rotate4reflect, rotate8reflect or permute", symmetries)
+	}
+
+	step := neighbours / rotations
+
+	return func(inputs []stateSet) [][]stateSet {
+		var arrangements [][]stateSet
+		seen := make(map[string]bool)
+
+		for rotation := 0; rotation < rotations; rotation++ {
+			for _, mirrored := range []bool{false, true} {
+				if mirrored && !reflect {
+					continue
+				}
+
+				arrangement := make([]stateSet, neighbours)
+				for i := range arrangement {
+					source := i
+					if mirrored {
+						source = (neighbours - i) % neighbours
+					}
+
+					arrangement[i] = inputs[(source+rotation*step)%neighbours]
+				}
+
+				if key := fmt.Sprint(arrangement); !seen[key] {
+					seen[key] = true
+					arrangements = append(arrangements, arrangement)
+				}
+			}
+		}
+
+		return arrangements
+	}, nil
+}
+
+// Lists every distinct ordering of the inputs, in lexicographic order
+func permuteInputs(inputs []stateSet) [][]stateSet {
+	current := append([]stateSet{}, inputs...)
+	sort.Slice(current, func(i, j int) bool { return current[i].less(current[j]) })
+
+	var arrangements [][]stateSet
+
+	for {
+		arrangements = append(arrangements, append([]stateSet{}, current...))
+
+		// The next permutation: find the last ascent, swap it with the last bigger input after it, and reverse the tail
+		i := len(current) - 2
+		for i >= 0 && !current[i].less(current[i+1]) {
+			i--
+		}
+		if i < 0 {
+			return arrangements
+		}
+
+		j := len(current) - 1
+		for !current[i].less(current[j]) {
+			j--
+		}
+
+		current[i], current[j] = current[j], current[i]
+		for left, right := i+1, len(current)-1; left < right; left, right = left+1, right-1 {
+			current[left], current[right] = current[right], current[left]
+		}
+	}
+}
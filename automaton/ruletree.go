@@ -0,0 +1,119 @@
+package automaton
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The cells a Golly tree reads in its own order, the center last
+var treeOffsets = map[int][]Point{
+	// NW, NE, SW, SE, N, W, E, S
+	8: {{X: -1, Y: -1}, {X: 1, Y: -1}, {X: -1, Y: 1}, {X: 1, Y: 1}, {X: 0, Y: -1}, {X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: 0}},
+	// N, W, E, S
+	4: {{X: 0, Y: -1}, {X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: 0}},
+}
+
+//* -------------------------
+//* RULE TREE
+//* -------------------------
+// Decision tree of a Golly @TREE, which branches on the state of one cell per level
+// Level 1 nodes hold the new states, higher nodes hold the indexes of the nodes one level down
+type ruleTree struct {
+	nodes [][]int
+	root  int
+}
+
+func (t *ruleTree) next(cells []int) (int, bool) {
+	node := t.root
+
+	for _, state := range cells {
+		if state >= len(t.nodes[node]) {
+			return 0, false
+		}
+
+		node = t.nodes[node][state]
+	}
+
+	// After the last level, node is the new state
+	return node, true
+}
+
+// Parses the body of a @TREE section, or a bare .tree file:
+// num_states, num_neighbors (4 or 8) and num_nodes settings, then one line per node, which is its level followed by a value per state
+// The last node is the root
+func parseRuleTree(text string) (*GollyRule, error) {
+	settings := make(map[string]int)
+	tree := &ruleTree{}
+	var levels []int
+
+	for _, line := range gollyLines(text) {
+		if equals := strings.IndexByte(line, '='); equals >= 0 {
+			value, err := strconv.Atoi(strings.TrimSpace(line[equals+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("setting %q is not a number", line)
+			}
+
+			settings[strings.TrimSpace(line[:equals])] = value
+			continue
+		}
+
+		states, neighbours := settings["num_states"], settings["num_neighbors"]
+		if !between(states, 2, MAX_GOLLY_STATES) {
+			return nil, fmt.Errorf("num_states must be between 2 and %d, got %d", MAX_GOLLY_STATES, states)
+		}
+		if _, ok := treeOffsets[neighbours]; !ok {
+			return nil, fmt.Errorf("num_neighbors must be 4 or 8, got %d", neighbours)
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != states+1 {
+			return nil, fmt.Errorf("node %d has %d values instead of a level and %d states", len(tree.nodes), len(fields)-1, states)
+		}
+
+		numbers := make([]int, len(fields))
+		for i, field := range fields {
+			number, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("node %d: %q is not a number", len(tree.nodes), field)
+			}
+
+			numbers[i] = number
+		}
+
+		level, values := numbers[0], numbers[1:]
+		for _, value := range values {
+			// Level 1 holds states, higher levels hold nodes that came before, one level down
+			if level == 1 && !between(value, 0, states-1) {
+				return nil, fmt.Errorf("node %d: state %d is not between 0 and %d", len(tree.nodes), value, states-1)
+			}
+			if level > 1 && (!between(value, 0, len(tree.nodes)-1) || levels[value] != level-1) {
+				return nil, fmt.Errorf("node %d: %d is not a node of level %d", len(tree.nodes), value, level-1)
+			}
+		}
+
+		tree.nodes = append(tree.nodes, values)
+		levels = append(levels, level)
+	}
+
+	if len(tree.nodes) == 0 {
+		return nil, fmt.Errorf("the tree has no nodes")
+	}
+	if nodes, ok := settings["num_nodes"]; ok && nodes != len(tree.nodes) {
+		return nil, fmt.Errorf("num_nodes is %d, but the tree has %d nodes", nodes, len(tree.nodes))
+	}
+
+	tree.root = len(tree.nodes) - 1
+	offsets := treeOffsets[settings["num_neighbors"]]
+
+	if levels[tree.root] != len(offsets) {
+		return nil, fmt.Errorf("the root is at level %d, but %d cells are read", levels[tree.root], len(offsets))
+	}
+
+	neighborhood := "moore"
+	if len(offsets) == 5 {
+		neighborhood = "vonneumann"
+	}
+
+	return newGollyRule(settings["num_states"], neighborhood, offsets, tree), nil
+}
@@ -0,0 +1,248 @@
+package automaton
+
+import "errors"
+
+// Particles of a Sandbox, stored as the state of their dot
+type Material int
+
+const (
+	MATERIAL_SAND  Material = 1
+	MATERIAL_WATER Material = 2
+	MATERIAL_STONE Material = 3
+	MATERIAL_FIRE  Material = 4
+	MATERIAL_SMOKE Material = 5
+)
+
+// How a material moves every tick
+type Flow int
+
+const (
+	FLOW_STATIC Flow = iota // Never moves
+	FLOW_POWDER             // Falls down, or slides off diagonally
+	FLOW_LIQUID             // Like powder, but spreads sideways when it can not fall
+	FLOW_GAS                // Like liquid, but rises instead of falling
+)
+
+var sandPalette = Palette{
+	{State: int(MATERIAL_SAND), Name: "sand", Fill: mustParseHexColor("#fcc419")},
+	{State: int(MATERIAL_WATER), Name: "water", Fill: mustParseHexColor("#339af0")},
+	{State: int(MATERIAL_STONE), Name: "stone", Fill: mustParseHexColor("#868e96")},
+	{State: int(MATERIAL_FIRE), Name: "fire", Fill: mustParseHexColor("#ff6b6b")},
+	{State: int(MATERIAL_SMOKE), Name: "smoke", Fill: mustParseHexColor("#495057")},
+}
+
+type materialProperties struct {
+	density int // Heavier particles sink through lighter ones that can move
+	flow    Flow
+	decay   float64  // Chance every tick of turning into decayTo
+	decayTo Material // 0 to vanish
+}
+
+var materials = map[Material]materialProperties{
+	MATERIAL_SAND:  {density: 20, flow: FLOW_POWDER},
+	MATERIAL_WATER: {density: 10, flow: FLOW_LIQUID},
+	MATERIAL_STONE: {density: 100, flow: FLOW_STATIC},
+	MATERIAL_FIRE:  {density: 2, flow: FLOW_GAS, decay: 0.1, decayTo: MATERIAL_SMOKE},
+	MATERIAL_SMOKE: {density: 1, flow: FLOW_GAS, decay: 0.02},
+}
+
+func (m Material) String() string {
+	for _, entry := range sandPalette {
+		if entry.State == int(m) {
+			return entry.Name
+		}
+	}
+
+	return "unknown"
+}
+
+func (m Material) Density() int {
+	return materials[m].density
+}
+
+func (m Material) Flow() Flow {
+	return materials[m].flow
+}
+
+// Makes a dot of a material, with the fill of its type
+func NewParticle(coords Point, material Material, parentGrid *Grid) *Dot {
+	fill, _ := sandPalette.Fill(int(material))
+
+	return NewStateDot(coords, int(material), fill, parentGrid)
+}
+
+//* -------------------------
+//* SANDBOX
+//* -------------------------
+// Falling-sand game on a grid, where particles move cell by cell instead of following a rule
+// Every tick, every particle moves at most once: sand piles up, water spreads out, fire and smoke rise and burn out,
+// stone stays where it is, and water puts out fire
+// The edges of the grid are walls, whatever its boundary, and chance comes from the grid's seed
+type Sandbox struct {
+	grid      *Grid
+	moved     map[*Dot]bool // Particles that already moved this tick
+	leftFirst bool          // Rows are scanned in alternating directions, so nothing drifts one way
+}
+
+func NewSandbox(grid *Grid) (*Sandbox, error) {
+	if grid.Unbounded() {
+		return nil, errors.New("a sandbox needs a bounded grid, so particles have a floor to land on")
+	}
+
+	return &Sandbox{grid: grid, moved: make(map[*Dot]bool)}, nil
+}
+
+func (s *Sandbox) Grid() *Grid {
+	return s.grid
+}
+
+// Returns the materials that can be painted, with their fills
+func (s *Sandbox) Palette() Palette {
+	return sandPalette
+}
+
+// Empties the grid and starts scanning from the same side as a new sandbox, so the same particles fall the same way as before
+func (s *Sandbox) Restart() {
+	s.grid.Restart()
+	s.moved = make(map[*Dot]bool)
+	s.leftFirst = false
+}
+
+// Replaces whatever is at coords with a particle
+func (s *Sandbox) Paint(coords Point, material Material) {
+	if _, err := s.grid.Get(coords); err != nil {
+		return
+	}
+
+	NewParticle(coords, material, s.grid)
+}
+
+// Moves every particle once, from the bottom row up
+// Particles are looked up in the grid as it changes instead of from a snapshot (like Grid.ForEach takes),
+// so the moved set is what keeps a falling particle from being met again in the row below
+func (s *Sandbox) Step() {
+	s.moved = make(map[*Dot]bool, len(s.moved))
+	s.leftFirst = !s.leftFirst
+
+	width, height := s.grid.Width(), s.grid.Height()
+
+	for y := height - 1; y >= 0; y-- {
+		for i := 0; i < width; i++ {
+			x := i
+			if !s.leftFirst {
+				x = width - 1 - i
+			}
+
+			if dot, _ := s.grid.Get(Point{X: x, Y: y}); dot != nil && !s.moved[dot] {
+				s.update(dot)
+			}
+		}
+	}
+}
+
+func (s *Sandbox) update(dot *Dot) {
+	material := Material(dot.State())
+	properties := materials[material]
+	coords := dot.Position()
+
+	if material == MATERIAL_FIRE && s.touches(coords, MATERIAL_WATER) {
+		s.replace(dot, MATERIAL_SMOKE)
+		return
+	}
+
+	if properties.decay > 0 && s.grid.Rand().Float64() < properties.decay {
+		s.replace(dot, properties.decayTo)
+		return
+	}
+
+	if properties.flow == FLOW_STATIC {
+		return
+	}
+
+	dy := 1
+	if properties.flow == FLOW_GAS {
+		dy = -1
+	}
+
+	// Straight ahead first, then the diagonals and the sides in a random order
+	side := 1
+	if s.grid.Rand().Intn(2) == 0 {
+		side = -1
+	}
+
+	targets := []Point{
+		{X: coords.X, Y: coords.Y + dy},
+		{X: coords.X + side, Y: coords.Y + dy},
+		{X: coords.X - side, Y: coords.Y + dy},
+	}
+	if properties.flow != FLOW_POWDER {
+		targets = append(targets, Point{X: coords.X + side, Y: coords.Y}, Point{X: coords.X - side, Y: coords.Y})
+	}
+
+	for _, target := range targets {
+		if s.moveTo(dot, target, dy) {
+			return
+		}
+	}
+}
+
+// Moves dot into target if it is empty, or swaps it with a particle it can sink or rise through
+// Returns whether it moved
+func (s *Sandbox) moveTo(dot *Dot, target Point, dy int) bool {
+	other, err := s.grid.Get(target)
+	if err != nil {
+		return false
+	}
+
+	if other == nil {
+		if dot.MoveTo(target) != nil {
+			return false
+		}
+
+		s.moved[dot] = true
+		return true
+	}
+
+	// Gases only rise through liquids and other gases, not through piles of powder
+	flow := Material(other.State()).Flow()
+	if s.moved[other] || flow == FLOW_STATIC || dy < 0 && flow == FLOW_POWDER {
+		return false
+	}
+
+	// Falling particles sink through lighter ones, rising ones through heavier ones
+	density, otherDensity := Material(dot.State()).Density(), Material(other.State()).Density()
+	if dy > 0 && density <= otherDensity || dy < 0 && density >= otherDensity {
+		return false
+	}
+
+	if s.grid.Swap(dot.Position(), target) != nil {
+		return false
+	}
+
+	s.moved[dot] = true
+	s.moved[other] = true
+	return true
+}
+
+// Turns a particle into another material, or removes it for 0
+func (s *Sandbox) replace(dot *Dot, material Material) {
+	coords := dot.Position()
+
+	if material == 0 {
+		s.grid.Remove(coords)
+		return
+	}
+
+	s.moved[NewParticle(coords, material, s.grid)] = true
+}
+
+// Whether any of the 4 orthogonal neighbours of coords is of a material
+func (s *Sandbox) touches(coords Point, material Material) bool {
+	for _, offset := range [4]Point{{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}} {
+		if dot, _ := s.grid.Get(Point{X: coords.X + offset.X, Y: coords.Y + offset.Y}); dot != nil && Material(dot.State()) == material {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,132 @@
+package automaton
+
+import "testing"
+
+func newTestSandbox(t *testing.T, width, height int) *Sandbox {
+	t.Helper()
+
+	sandbox, err := NewSandbox(NewGrid(width, height))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sandbox
+}
+
+// Returns the material at coords, 0 when empty
+func materialAt(sandbox *Sandbox, coords Point) Material {
+	if dot := sandbox.Grid().Lookup(coords); dot != nil {
+		return Material(dot.State())
+	}
+
+	return 0
+}
+
+// A particle moves at most one cell per tick, even when the cell it lands in is visited after it
+func TestSandFallsOneCellPerTick(t *testing.T) {
+	sandbox := newTestSandbox(t, 3, 10)
+	sandbox.Paint(Point{X: 1, Y: 0}, MATERIAL_SAND)
+
+	for tick := 1; tick <= 12; tick++ {
+		sandbox.Step()
+
+		want := Point{X: 1, Y: tick}
+		if tick > 9 {
+			want.Y = 9 // Resting on the floor
+		}
+
+		if materialAt(sandbox, want) != MATERIAL_SAND || sandbox.Grid().NumUsedCells() != 1 {
+			t.Fatalf("tick %d: expected the grain at %v", tick, want)
+		}
+	}
+}
+
+// Rising particles are met again in the rows above them, which must not move them a second time
+func TestSmokeRisesOneCellPerTick(t *testing.T) {
+	sandbox := newTestSandbox(t, 1, 10)
+	sandbox.Paint(Point{X: 0, Y: 9}, MATERIAL_SMOKE)
+
+	for tick := 1; tick <= 9; tick++ {
+		sandbox.Step()
+
+		// Smoke can burn out along the way
+		if sandbox.Grid().NumUsedCells() == 0 {
+			return
+		}
+
+		if want := (Point{X: 0, Y: 9 - tick}); materialAt(sandbox, want) != MATERIAL_SMOKE {
+			t.Fatalf("tick %d: expected the smoke at %v", tick, want)
+		}
+	}
+}
+
+// Sand sinks through water one swap per tick, and the water it passes only moves up once
+func TestSandSinksThroughWater(t *testing.T) {
+	sandbox := newTestSandbox(t, 1, 5)
+	sandbox.Paint(Point{X: 0, Y: 0}, MATERIAL_SAND)
+	for y := 1; y < 5; y++ {
+		sandbox.Paint(Point{X: 0, Y: y}, MATERIAL_WATER)
+	}
+
+	for tick := 1; tick <= 6; tick++ {
+		sandbox.Step()
+
+		sand := tick
+		if sand > 4 {
+			sand = 4
+		}
+
+		for y := 0; y < 5; y++ {
+			want := MATERIAL_WATER
+			if y == sand {
+				want = MATERIAL_SAND
+			}
+
+			if got := materialAt(sandbox, Point{X: 0, Y: y}); got != want {
+				t.Fatalf("tick %d: cell %d is %v, expected %v", tick, y, got, want)
+			}
+		}
+	}
+}
+
+// A column of sand spreads into a pile where every grain rests on something, and then stops moving
+func TestSandColumnSettles(t *testing.T) {
+	const width, height, grains = 7, 8, 6
+
+	sandbox := newTestSandbox(t, width, height)
+	for y := 0; y < grains; y++ {
+		sandbox.Paint(Point{X: 3, Y: y}, MATERIAL_SAND)
+	}
+	sandbox.Paint(Point{X: 0, Y: height - 1}, MATERIAL_STONE)
+
+	for tick := 0; tick < 30; tick++ {
+		sandbox.Step()
+	}
+
+	settled := cellStates(sandbox.Grid())
+	if sandbox.Grid().NumUsedCells() != grains+1 {
+		t.Fatalf("%d particles, expected %d grains and the stone", sandbox.Grid().NumUsedCells(), grains)
+	}
+	if materialAt(sandbox, Point{X: 0, Y: height - 1}) != MATERIAL_STONE {
+		t.Fatal("stone moved")
+	}
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height-1; y++ {
+			if materialAt(sandbox, Point{X: x, Y: y}) == MATERIAL_SAND && materialAt(sandbox, Point{X: x, Y: y + 1}) == 0 {
+				t.Fatalf("grain at %v has nothing below it", Point{X: x, Y: y})
+			}
+		}
+	}
+
+	// A settled pile has nowhere left to slide
+	sandbox.Step()
+	after := cellStates(sandbox.Grid())
+	for x := range settled {
+		for y := range settled[x] {
+			if settled[x][y] != after[x][y] {
+				t.Fatalf("cell %v still changes after settling", Point{X: x, Y: y})
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package automaton
+
+import "sort"
+
+//* -------------------------
+//* SPARSE MATRIX
+//* -------------------------
+// Unbounded storage that only keeps the live cells, keyed by their coords
+type SparseMatrix map[Point]*Dot
+
+func NewSparseMatrix() SparseMatrix {
+	return make(SparseMatrix)
+}
+
+func (sm SparseMatrix) Get(coords Point) *Dot {
+	return sm[coords]
+}
+
+func (sm SparseMatrix) Set(coords Point, dot *Dot) {
+	if dot == nil {
+		delete(sm, coords)
+		return
+	}
+
+	sm[coords] = dot
+}
+
+// Every coord is inside an unbounded matrix
+func (sm SparseMatrix) InBounds(coords Point) bool {
+	return true
+}
+
+// Visits the cells in the same column by column order as a ScreenPixelMatrix, so runs are reproducible
+func (sm SparseMatrix) ForEach(callback func(dot *Dot)) {
+	coords := make([]Point, 0, len(sm))
+	for p := range sm {
+		coords = append(coords, p)
+	}
+
+	sortPoints(coords)
+
+	for _, p := range coords {
+		callback(sm[p])
+	}
+}
+
+func (sm SparseMatrix) Empty() Matrix {
+	return NewSparseMatrix()
+}
+
+func (sm SparseMatrix) Clone() Matrix {
+	clone := make(SparseMatrix, len(sm))
+	for p, dot := range sm {
+		clone[p] = dot
+	}
+
+	return clone
+}
+
+// Returns the top left and bottom right corners of the smallest area containing every live cell
+// Both are the origin if there are no live cells
+func (sm SparseMatrix) Extent() (Point, Point) {
+	var min, max Point
+	first := true
+
+	for p := range sm {
+		if first {
+			min, max = p, p
+			first = false
+			continue
+		}
+
+		if p.X < min.X {
+			min.X = p.X
+		}
+		if p.Y < min.Y {
+			min.Y = p.Y
+		}
+		if p.X > max.X {
+			max.X = p.X
+		}
+		if p.Y > max.Y {
+			max.Y = p.Y
+		}
+	}
+
+	return min, max
+}
+
+// Sorts column by column, then row by row
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+
+		return points[i].Y < points[j].Y
+	})
+}
@@ -0,0 +1,144 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//* -------------------------
+//* PROBABILISTIC RULE
+//* -------------------------
+// Binary rule that only follows another rule some of the time
+// Cells the base rule brings to life are born with the birth chance, cells it keeps alive survive with the survival chance,
+// and afterwards every cell flips with the noise chance, so noise also shows up where nothing else happens
+// Written like B3/S23:birth=0.9,survival=0.99,noise=0.0001, chances that are left out keep the base rule as it is
+type ProbabilisticRule struct {
+	Kernel
+	base                   Convolver
+	birth, survival, noise float64
+}
+
+func NewProbabilisticRule(base Convolver, birth, survival, noise float64) (*ProbabilisticRule, error) {
+	// Palettes leave out the empty state, so 2-state rules have at most one entry
+	if multiState, ok := base.(MultiStateRule); ok && len(multiState.Palette()) > 1 {
+		return nil, errors.New("only rules with 2 states can be made probabilistic")
+	}
+
+	for name, chance := range map[string]float64{"birth": birth, "survival": survival, "noise": noise} {
+		if chance < 0 || chance > 1 {
+			return nil, fmt.Errorf("%s chance %v is not between 0 and 1", name, chance)
+		}
+	}
+
+	return &ProbabilisticRule{Kernel: Kernel{size: base.Size()}, base: base, birth: birth, survival: survival, noise: noise}, nil
+}
+
+func (r *ProbabilisticRule) ApplyKernel(win *Window) *Dot {
+	return r.next(win.grid, win.GridCoords(), win.Center(), r.base.ApplyKernel(win))
+}
+
+// Lets the base rule read straight from the grid when it can
+func (r *ProbabilisticRule) ApplyCell(grid *Grid, coords Point) *Dot {
+	var result *Dot
+	if cellConv, ok := r.base.(CellConvolver); ok {
+		result = cellConv.ApplyCell(grid, coords)
+	} else {
+		result = r.base.ApplyKernel(NewWindow(grid, coords, r.Size()))
+	}
+
+	return r.next(grid, coords, grid.Lookup(coords), result)
+}
+
+func (r *ProbabilisticRule) Prepare(grid *Grid) interface{} {
+	if prepared, ok := r.base.(PreparedConvolver); ok {
+		return prepared.Prepare(grid)
+	}
+
+	return nil
+}
+
+// Draw 0 decides birth or survival and draw 1 the noise
+func (r *ProbabilisticRule) next(grid *Grid, coords Point, center, result *Dot) *Dot {
+	switch {
+	case center == nil && result != nil && grid.CellRandom(coords, 0) >= r.birth:
+		result = nil
+	case center != nil && result != nil && grid.CellRandom(coords, 0) >= r.survival:
+		result = nil
+	}
+
+	if r.noise > 0 && grid.CellRandom(coords, 1) < r.noise {
+		if result != nil {
+			return nil
+		}
+
+		return NewDot(coords, nil)
+	}
+
+	return result
+}
+
+func (r *ProbabilisticRule) Size() int {
+	return r.size
+}
+
+func (r *ProbabilisticRule) Stochastic() bool {
+	return true
+}
+
+func (r *ProbabilisticRule) Hexagonal() bool {
+	return IsHexagonal(r.base)
+}
+
+func (r *ProbabilisticRule) Base() Convolver {
+	return r.base
+}
+
+// Returns the rule as ParseRule reads it, leaving out the chances that change nothing
+func (r *ProbabilisticRule) String() string {
+	var chances []string
+
+	for _, chance := range []struct {
+		name          string
+		value, normal float64
+	}{{"birth", r.birth, 1}, {"survival", r.survival, 1}, {"noise", r.noise, 0}} {
+		if chance.value != chance.normal {
+			chances = append(chances, chance.name+"="+strconv.FormatFloat(chance.value, 'g', -1, 64))
+		}
+	}
+
+	return fmt.Sprintf("%v:%s", r.base, strings.Join(chances, ","))
+}
+
+// Parses the chances after the colon of a rule like B3/S23:birth=0.9,noise=0.001
+func parseProbabilistic(base Convolver, chances string) (*ProbabilisticRule, error) {
+	birth, survival, noise := 1.0, 1.0, 0.0
+
+	for _, part := range strings.Split(chances, ",") {
+		equals := strings.IndexByte(part, '=')
+		if equals < 0 {
+			return nil, fmt.Errorf("%q is not a chance like birth=0.5", part)
+		}
+
+		key, value := part[:equals], part[equals+1:]
+
+		chance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("chance %q is not a number", value)
+		}
+
+		switch strings.ToLower(key) {
+		case "birth":
+			birth = chance
+		case "survival":
+			survival = chance
+		case "noise":
+			noise = chance
+		default:
+			return nil, fmt.Errorf("unknown chance %q, expected birth, survival or noise", key)
+		}
+	}
+
+	return NewProbabilisticRule(base, birth, survival, noise)
+}
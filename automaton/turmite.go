@@ -0,0 +1,369 @@
+package automaton
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Which way an agent faces, turning right goes clockwise
+type Heading int
+
+const (
+	North Heading = iota
+	East
+	South
+	West
+)
+
+var headingNames = [...]string{"north", "east", "south", "west"}
+
+func (h Heading) String() string {
+	return headingNames[h]
+}
+
+// Returns the heading after a turn
+func (h Heading) Turn(turn Turn) Heading {
+	switch turn {
+	case TURN_RIGHT:
+		return (h + 1) % 4
+	case TURN_AROUND:
+		return (h + 2) % 4
+	case TURN_LEFT:
+		return (h + 3) % 4
+	}
+
+	return h
+}
+
+// Returns the coords one cell ahead
+func (h Heading) Step(coords Point) Point {
+	switch h {
+	case North:
+		return Point{X: coords.X, Y: coords.Y - 1}
+	case East:
+		return Point{X: coords.X + 1, Y: coords.Y}
+	case South:
+		return Point{X: coords.X, Y: coords.Y + 1}
+	}
+
+	return Point{X: coords.X - 1, Y: coords.Y}
+}
+
+// Turns use the numbers of the standard turmite transition tables
+type Turn int
+
+const (
+	TURN_NONE   Turn = 1
+	TURN_RIGHT  Turn = 2
+	TURN_AROUND Turn = 4
+	TURN_LEFT   Turn = 8
+)
+
+// Well known turmites, which ParseTurmite accepts by name (case insensitive)
+var NamedTurmites = map[string]string{
+	"langton":   "RL",
+	"symmetric": "LLRR",
+	"square":    "LRRRRRLLR",
+	"fibonacci": "{{{1,8,1},{1,8,1}},{{1,2,1},{0,1,0}}}",
+}
+
+//* -------------------------
+//* TURMITE RULE
+//* -------------------------
+// What an agent does for every combination of its own state and the colour of the cell under it
+// Colour 0 is an empty cell, other colours are dots with that state
+type TurmiteRule struct {
+	table [][]TurmiteTransition // Indexed [state][colour]
+	fills []color.Color         // Indexed by colour, 0 (empty) is nil
+	spec  string
+}
+
+type TurmiteTransition struct {
+	Write int // Colour left behind
+	Turn  Turn
+	Next  int // State of the agent afterwards
+}
+
+// table is indexed [state][colour], and every state needs a transition for every colour
+func NewTurmiteRule(table [][]TurmiteTransition) (*TurmiteRule, error) {
+	if len(table) == 0 || len(table[0]) < 2 {
+		return nil, fmt.Errorf("a turmite needs at least 1 state and 2 colours")
+	}
+
+	colours := len(table[0])
+
+	for state, transitions := range table {
+		if len(transitions) != colours {
+			return nil, fmt.Errorf("state %d has %d colours instead of %d", state, len(transitions), colours)
+		}
+
+		for colour, transition := range transitions {
+			if !between(transition.Write, 0, colours-1) {
+				return nil, fmt.Errorf("state %d colour %d writes colour %d, which does not exist", state, colour, transition.Write)
+			}
+			if !between(transition.Next, 0, len(table)-1) {
+				return nil, fmt.Errorf("state %d colour %d goes to state %d, which does not exist", state, colour, transition.Next)
+			}
+			if transition.Turn != TURN_NONE && transition.Turn != TURN_RIGHT && transition.Turn != TURN_AROUND && transition.Turn != TURN_LEFT {
+				return nil, fmt.Errorf("state %d colour %d has turn %d, expected 1, 2, 4 or 8", state, colour, transition.Turn)
+			}
+		}
+	}
+
+	rule := &TurmiteRule{table: table}
+	rule.fills = append([]color.Color{nil}, Gradient(dotFill, turmiteFill, colours-1)...)
+
+	return rule, nil
+}
+
+// The last colour fades towards this
+var turmiteFill = mustParseHexColor("#e64980")
+
+func (r *TurmiteRule) States() int {
+	return len(r.table)
+}
+
+func (r *TurmiteRule) Colours() int {
+	return len(r.table[0])
+}
+
+// Returns the rule as ParseTurmite reads it
+func (r *TurmiteRule) String() string {
+	if r.spec != "" {
+		return r.spec
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+
+	for state, transitions := range r.table {
+		if state > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('{')
+
+		for colour, transition := range transitions {
+			if colour > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(fmt.Sprintf("{%d,%d,%d}", transition.Write, transition.Turn, transition.Next))
+		}
+
+		sb.WriteByte('}')
+	}
+
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+// Parses a turmite written as a transition table like {{{1,2,0},{0,8,0}}} (Langton's ant),
+// where every state has a {colour to write, turn, next state} for every colour,
+// as a multi-colour ant like RL or LLRR, which turns by the letter of the colour under it (R, L, N for none or U for around),
+// or as one of the NamedTurmites
+func ParseTurmite(spec string) (*TurmiteRule, error) {
+	spec = strings.ReplaceAll(strings.TrimSpace(spec), " ", "")
+	if named, ok := NamedTurmites[strings.ToLower(spec)]; ok {
+		spec = named
+	}
+
+	var rule *TurmiteRule
+	var err error
+
+	if strings.HasPrefix(spec, "{") {
+		rule, err = parseTurmiteTable(spec)
+	} else {
+		rule, err = parseAnt(spec)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid turmite %q: %v", spec, err)
+	}
+
+	rule.spec = spec
+
+	return rule, nil
+}
+
+// Every colour turns by its letter and is painted over with the next colour
+func parseAnt(letters string) (*TurmiteRule, error) {
+	turns := map[rune]Turn{'R': TURN_RIGHT, 'L': TURN_LEFT, 'N': TURN_NONE, 'U': TURN_AROUND}
+
+	var transitions []TurmiteTransition
+	for colour, letter := range strings.ToUpper(letters) {
+		turn, ok := turns[letter]
+		if !ok {
+			return nil, fmt.Errorf("%q is not a turn, expected R, L, N or U", letter)
+		}
+
+		transitions = append(transitions, TurmiteTransition{Write: (colour + 1) % len(letters), Turn: turn})
+	}
+
+	return NewTurmiteRule([][]TurmiteTransition{transitions})
+}
+
+func parseTurmiteTable(spec string) (*TurmiteRule, error) {
+	var table [][]TurmiteTransition
+	var numbers []int
+	var depth int
+	number := ""
+
+	for _, char := range spec {
+		switch {
+		case char >= '0' && char <= '9':
+			number += string(char)
+			continue
+		case number != "":
+			value, _ := strconv.Atoi(number)
+			numbers = append(numbers, value)
+			number = ""
+		}
+
+		switch char {
+		case '{':
+			depth++
+			if depth == 2 {
+				table = append(table, nil)
+			}
+			if depth > 3 {
+				return nil, fmt.Errorf("braces are nested too deep")
+			}
+		case '}':
+			if depth == 3 {
+				if len(numbers) != 3 {
+					return nil, fmt.Errorf("every transition needs 3 numbers, got %v", numbers)
+				}
+
+				state := len(table) - 1
+				table[state] = append(table[state], TurmiteTransition{Write: numbers[0], Turn: Turn(numbers[1]), Next: numbers[2]})
+				numbers = nil
+			}
+			depth--
+		case ',':
+		default:
+			return nil, fmt.Errorf("unexpected %q", char)
+		}
+
+		if depth < 0 {
+			return nil, fmt.Errorf("unbalanced braces")
+		}
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced braces")
+	}
+
+	return NewTurmiteRule(table)
+}
+
+//* -------------------------
+//* AGENT
+//* -------------------------
+// A turmite walking over a grid, which only changes the cell under it
+type Agent struct {
+	position Point
+	heading  Heading
+	state    int
+	rule     *TurmiteRule
+}
+
+func NewAgent(coords Point, heading Heading, rule *TurmiteRule) *Agent {
+	return &Agent{position: coords, heading: heading, rule: rule}
+}
+
+func (a *Agent) String() string {
+	return fmt.Sprintf("Agent{ x: %d, y: %d, heading: %v, state: %d }", a.position.X, a.position.Y, a.heading, a.state)
+}
+
+func (a *Agent) Position() Point {
+	return a.position
+}
+
+func (a *Agent) Heading() Heading {
+	return a.heading
+}
+
+func (a *Agent) State() int {
+	return a.state
+}
+
+// Reads the cell under the agent, writes it, turns and moves one cell ahead
+// Where the boundary has nothing ahead, the agent turns around instead of moving
+func (a *Agent) Step(grid *Grid) {
+	var colour int
+	if dot := grid.Lookup(a.position); dot != nil {
+		colour = mod(dot.State(), a.rule.Colours())
+	}
+
+	transition := a.rule.table[a.state][colour]
+
+	if transition.Write == 0 {
+		grid.Remove(a.position)
+	} else if colour != transition.Write {
+		NewStateDot(a.position, transition.Write, a.rule.fills[transition.Write], grid)
+	}
+
+	a.state = transition.Next
+	a.heading = a.heading.Turn(transition.Turn)
+
+	ahead := a.heading.Step(a.position)
+	if !grid.data.InBounds(ahead) {
+		var ok bool
+		if ahead, ok = grid.boundary.resolve(ahead, grid.width, grid.height); !ok {
+			a.heading = a.heading.Turn(TURN_AROUND)
+			return
+		}
+	}
+
+	a.position = ahead
+}
+
+//* -------------------------
+//* AGENT LAYER
+//* -------------------------
+// Agents sharing a grid, which move one after another every tick in the order they were added
+type AgentLayer struct {
+	grid   *Grid
+	agents []*Agent
+}
+
+func NewAgentLayer(grid *Grid) *AgentLayer {
+	return &AgentLayer{grid: grid}
+}
+
+func (l *AgentLayer) Grid() *Grid {
+	return l.grid
+}
+
+func (l *AgentLayer) Agents() []*Agent {
+	return l.agents
+}
+
+func (l *AgentLayer) Add(agent *Agent) {
+	l.agents = append(l.agents, agent)
+}
+
+// Removes every agent at coords
+func (l *AgentLayer) RemoveAt(coords Point) {
+	kept := l.agents[:0]
+	for _, agent := range l.agents {
+		if agent.position != coords {
+			kept = append(kept, agent)
+		}
+	}
+
+	l.agents = kept
+}
+
+func (l *AgentLayer) Clear() {
+	l.agents = nil
+}
+
+// Moves every agent once
+func (l *AgentLayer) Step() {
+	for _, agent := range l.agents {
+		agent.Step(l.grid)
+	}
+}
@@ -0,0 +1,728 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"math/rand"
+	"sync"
+)
+
+//* -------------------------
+//* GRID
+//* -------------------------
+type Grid struct {
+	data          Matrix
+	width, height int // 0 for unbounded grids
+	boundary      Boundary
+	borderDot     *Dot // What windows see past the edges with an AliveBorder
+	numUsedCells  int
+	workers       int         // Number of goroutines sharing a convolution
+	prepared      interface{} // What the rule's Prepare returned, during a convolution
+
+	seed       int64
+	rng        *rand.Rand // Seeded with seed, for randomness that is drawn one value after another
+	generation uint64     // Number of convolutions since the seed was set, so every generation draws other per-cell values
+
+	tracking     bool           // Whether convolutions only visit the neighbourhoods of changed cells
+	changed      map[Point]bool // Cells changed since the last convolution, nil when unknown
+	activeRegion []Point        // Cells visited by the last convolution
+}
+
+func NewGrid(width, height int) *Grid {
+	if width < 1 || height < 1 {
+		log.Fatalf("grid 'width' and 'height' must be at least 1, got %dx%d", width, height)
+	}
+
+	g := &Grid{width: width, height: height}
+	g.data = NewScreenPixelMatrix(width, height)
+	g.borderDot = NewDot(*NewPoint(-1, -1), nil)
+	g.SetSeed(DEFAULT_SEED)
+
+	return g
+}
+
+// Unbounded grid that only stores its live cells, so patterns can grow forever (and into negative coords)
+// The boundary does not apply, since windows never reach past the edges
+func NewSparseGrid() *Grid {
+	g := &Grid{data: NewSparseMatrix()}
+	g.borderDot = NewDot(*NewPoint(-1, -1), nil)
+	g.SetSeed(DEFAULT_SEED)
+
+	return g
+}
+
+func (g *Grid) String() string {
+	if g.Unbounded() {
+		return fmt.Sprintf("Grid{ unbounded, numUsedCells: %d }", g.numUsedCells)
+	}
+
+	return fmt.Sprintf("Grid{ width: %d, height: %d, numUsedCells: %d }", g.width, g.height, g.numUsedCells)
+}
+
+func (g *Grid) Unbounded() bool {
+	_, sparse := g.data.(SparseMatrix)
+	return sparse
+}
+
+func (g *Grid) Width() int {
+	return g.width
+}
+
+func (g *Grid) Height() int {
+	return g.height
+}
+
+func (g *Grid) Boundary() Boundary {
+	return g.boundary
+}
+
+// Decides what windows see past the edges of the grid
+func (g *Grid) SetBoundary(boundary Boundary) {
+	g.boundary = boundary
+	g.forgetChanges() // Cells along the edges now have different neighbours
+}
+
+func (g *Grid) Workers() int {
+	return g.workers
+}
+
+// Splits convolutions into vertical stripes, one per worker goroutine
+// Rules must then be safe to call from several goroutines at once, which they are as long as they only read the window
+// Unbounded grids always convolve on a single goroutine, since their matrix can not be written to concurrently
+func (g *Grid) SetWorkers(workers int) {
+	g.workers = workers
+}
+
+func (g *Grid) NumUsedCells() int {
+	return g.numUsedCells
+}
+
+func (g *Grid) Clear() {
+	g.ReplaceMatrix(g.CreateTempMatrix())
+	g.numUsedCells = 0
+}
+
+func (g Grid) CreateTempMatrix() Matrix {
+	return g.data.Empty()
+}
+
+// Returns the last col and row num that can contain values
+// Unbounded grids return the last col and row that currently contain values
+func (g *Grid) Bounds() (int, int) {
+	_, max := g.Extent()
+	return max.X, max.Y
+}
+
+// Returns the top left and bottom right corners of the area that can contain values
+// Unbounded grids return the smallest area that contains all current values instead
+func (g *Grid) Extent() (Point, Point) {
+	if sparse, ok := g.data.(SparseMatrix); ok {
+		return sparse.Extent()
+	}
+
+	return Point{}, Point{X: g.width - 1, Y: g.height - 1}
+}
+
+// TODO: return err (or nil?) if the coords are out of bounds
+func (g *Grid) Get(coords Point) (*Dot, error) {
+	if !g.data.InBounds(coords) {
+		maxX, maxY := g.Bounds()
+		return nil, fmt.Errorf("cannot 'Get' cell out of grid bounds. Accessing: %v of x: 0 - %d, y: 0 - %d", coords, maxX, maxY)
+	}
+
+	return g.data.Get(coords), nil
+}
+
+// Like Get, but coords outside the grid are mapped back onto it with the grid's boundary
+// Returns nil if the coords do not refer to any cell, unless the grid has an AliveBorder
+func (g *Grid) Lookup(coords Point) *Dot {
+	if g.data.InBounds(coords) {
+		return g.data.Get(coords)
+	}
+
+	resolved, ok := g.boundary.resolve(coords, g.width, g.height)
+	if !ok {
+		if g.boundary == AliveBorder {
+			return g.borderDot
+		}
+
+		return nil
+	}
+
+	return g.data.Get(resolved)
+}
+
+func (g *Grid) Move(currentCoords Point, newCoords Point, dot *Dot) error {
+	targetDot, err := g.Get(newCoords)
+
+	// Cell already has a dot
+	if targetDot != nil {
+		currentDot, _ := g.Get(currentCoords)
+		return fmt.Errorf("cannot move %v to cell with coords %v: the target cell is already occupied", currentDot, newCoords)
+	}
+	// Tried to access out of bounds
+	if err != nil {
+		currentDot, _ := g.Get(currentCoords)
+		return fmt.Errorf("cannot move %v to cell with coords %v: the target cell is outside the grid", currentDot, newCoords)
+	}
+
+	g.Remove(currentCoords)
+	g.Set(newCoords, dot)
+
+	return nil
+}
+
+// Like Move, but into an occupied cell, whose dot moves the other way
+func (g *Grid) Swap(coords Point, otherCoords Point) error {
+	dot, err := g.Get(coords)
+	if err != nil {
+		return fmt.Errorf("cannot swap cell with coords %v: %v", coords, err)
+	}
+
+	other, err := g.Get(otherCoords)
+	if err != nil {
+		return fmt.Errorf("cannot swap cell with coords %v: %v", otherCoords, err)
+	}
+
+	g.Remove(coords)
+	g.Remove(otherCoords)
+
+	if dot != nil {
+		g.Set(otherCoords, dot)
+	}
+	if other != nil {
+		g.Set(coords, other)
+	}
+
+	return nil
+}
+
+// Not really used with tempMatrix (in convolutions)
+func (g *Grid) Set(coords Point, dot *Dot) {
+	// Remove existing (if any) dot first, to also decrement usedCells etc
+	if dot, _ := g.Get(coords); dot != nil {
+		g.Remove(coords)
+	}
+
+	g.data.Set(coords, dot)
+	g.markChanged(coords)
+
+	dot.SetParentGrid(g)
+	dot.SetPosition(coords)
+
+	g.IncrementNumUsedCells()
+}
+
+func (g *Grid) ReplaceMatrix(data Matrix) {
+	g.data = data
+	g.forgetChanges()
+}
+
+func (g *Grid) Remove(coords Point) {
+	if dot, _ := g.Get(coords); dot == nil {
+		return
+	}
+
+	g.data.Set(coords, nil)
+	g.markChanged(coords)
+
+	g.DecrementNumUsedCells()
+}
+
+func (g *Grid) RandomOpenCell() (*Point, error) {
+	var openCells []Point
+
+	// Unbounded grids only pick between the open cells in between their current values
+	min, max := g.Extent()
+
+	for x := min.X; x <= max.X; x++ {
+		for y := min.Y; y <= max.Y; y++ {
+			p := Point{X: x, Y: y}
+			if g.data.Get(p) == nil {
+				openCells = append(openCells, p)
+			}
+		}
+	}
+
+	if len(openCells) == 0 {
+		return nil, errors.New("there are no more open cells")
+	}
+
+	randCellNum := g.rng.Intn(len(openCells))
+
+	return &openCells[randCellNum], nil
+}
+
+func (g *Grid) IncrementNumUsedCells() {
+	g.numUsedCells++
+}
+
+func (g *Grid) DecrementNumUsedCells() {
+	g.numUsedCells--
+}
+
+// Loop though all cells in grid and do an operation within a window (e.g. a kernel operation)
+// Unbounded grids only visit the cells within reach of a live cell, so rules that bring empty neighbourhoods to life will not spread into the void
+// With active tracking only the cells within reach of a changed cell are visited, the rest keep their value
+// func (g *Grid) Convolve(windowSize int, callback func(*Window) *Dot) {
+func (g *Grid) Convolve(conv Convolver) {
+	tempMatrix := g.CreateTempMatrix()
+
+	cells, partial := g.activeCells(conv)
+	if partial {
+		// Cells that are not visited keep their value
+		tempMatrix = g.data.Clone()
+	} else {
+		cells = g.convolutionCells(conv.Size() >> 1)
+	}
+	g.activeRegion = cells
+
+	if prepared, ok := conv.(PreparedConvolver); ok {
+		g.prepared = prepared.Prepare(g)
+	}
+
+	workers := g.workers
+	if workers < 1 || g.Unbounded() {
+		workers = 1
+	}
+
+	var changed []Point
+
+	if workers == 1 {
+		var delta int
+		delta, changed = g.convolveCells(conv, cells, tempMatrix)
+		g.numUsedCells += delta
+	} else {
+		// Every worker gets its own stripe of cells, and its own count of removed / added cells to merge afterwards
+		deltas := make([]int, workers)
+		changes := make([][]Point, workers)
+		stripeSize := (len(cells) + workers - 1) / workers
+
+		var wg sync.WaitGroup
+
+		for worker := 0; worker < workers; worker++ {
+			start, end := worker*stripeSize, (worker+1)*stripeSize
+			if start >= len(cells) {
+				break
+			}
+			if end > len(cells) {
+				end = len(cells)
+			}
+
+			wg.Add(1)
+			go func(worker int, stripe []Point) {
+				defer wg.Done()
+				deltas[worker], changes[worker] = g.convolveCells(conv, stripe, tempMatrix)
+			}(worker, cells[start:end])
+		}
+
+		wg.Wait()
+
+		for worker, delta := range deltas {
+			g.numUsedCells += delta
+			changed = append(changed, changes[worker]...)
+		}
+	}
+
+	g.prepared = nil
+	g.ReplaceMatrix(tempMatrix)
+	g.rememberChanges(changed)
+	g.generation++
+
+	// New dots made in the callback should not have a parentGrid and position in that grid yet, since it is born into the tempMatrix instead
+	// ...therefore we need to set the parent grid for every (newly created) dot here
+	// Other g.Set() functionality such as decrement/increment usedCells and setting position is handled separately above
+	// ... as to avoid using g.Set()
+	g.ForEach(func(dot *Dot) {
+		dot.parentGrid = g
+	})
+}
+
+// Returns what the rule being convolved returned from Prepare (see PreparedConvolver), nil outside of convolutions
+func (g *Grid) Prepared() interface{} {
+	return g.prepared
+}
+
+// Applies the rule to every cell in cells, writing the results into tempMatrix
+// Returns how much the number of used cells changed, so workers do not have to share the counter, and which cells changed
+func (g *Grid) convolveCells(conv Convolver, cells []Point, tempMatrix Matrix) (int, []Point) {
+	var windowSize int = conv.Size()
+	var callback func(*Window) *Dot = conv.ApplyKernel
+
+	cellConv, direct := conv.(CellConvolver)
+
+	var delta int
+	var changed []Point
+
+	for _, coords := range cells {
+		var cellVal *Dot
+		if direct {
+			cellVal = cellConv.ApplyCell(g, coords)
+		} else {
+			cellVal = callback(NewWindow(g, coords, windowSize))
+		}
+
+		if cellVal != nil {
+			cellVal.SetPosition(coords)
+		}
+
+		tempMatrix.Set(coords, cellVal)
+
+		// Keep track of removed / added cells
+		formerCellVal := g.data.Get(coords)
+		// If this cell used to have a value, but now doesn't
+		if formerCellVal != nil && cellVal == nil {
+			delta--
+		}
+		// If this cell had no value, but now it does
+		if formerCellVal == nil && cellVal != nil {
+			delta++
+		}
+
+		if g.tracking && formerCellVal != cellVal {
+			changed = append(changed, coords)
+		}
+	}
+
+	return delta, changed
+}
+
+// Returns the coords of every cell a convolution has to visit, column by column
+// That is every cell for bounded grids, and every cell within reach of a live cell for unbounded ones
+func (g *Grid) convolutionCells(reach int) []Point {
+	sparse, ok := g.data.(SparseMatrix)
+	if !ok {
+		cells := make([]Point, 0, g.width*g.height)
+
+		for x := 0; x < g.width; x++ {
+			for y := 0; y < g.height; y++ {
+				cells = append(cells, Point{X: x, Y: y})
+			}
+		}
+
+		return cells
+	}
+
+	visited := make(map[Point]bool)
+	var cells []Point
+
+	for coords := range sparse {
+		for x := coords.X - reach; x <= coords.X+reach; x++ {
+			for y := coords.Y - reach; y <= coords.Y+reach; y++ {
+				p := Point{X: x, Y: y}
+				if visited[p] {
+					continue
+				}
+
+				visited[p] = true
+				cells = append(cells, p)
+			}
+		}
+	}
+
+	sortPoints(cells)
+
+	return cells
+}
+
+// *NOTE: collisions can happen, if no intermediary temp matrix is used
+func (g *Grid) ForEach(callback func(dot *Dot)) {
+	// Adding all existing dots to a slice up front makes sure we will only call callback on every dot once
+	var dots []*Dot
+
+	g.data.ForEach(func(dot *Dot) {
+		dots = append(dots, dot)
+	})
+
+	if len(dots) == 0 {
+		return
+	}
+
+	for _, dot := range dots {
+		callback(dot)
+	}
+}
+
+//* -------------------------
+//* WINDOW
+//* -------------------------
+type Window struct {
+	grid   *Grid
+	center Point
+	size   int
+	matrix [][]*Dot
+}
+
+// Cells outside the grid are filled in according to the grid's boundary (nil if Bounded)
+func NewWindow(grid *Grid, coords Point, size int) *Window {
+	if size%2 != 1 {
+		log.Fatal("window 'size' can only be an odd number")
+	}
+
+	window := &Window{grid: grid, center: coords, size: size}
+
+	matrix := make([][]*Dot, 0, size) // matches ScreenPixelMatrix's type, but sized to the window instead of the grid
+
+	reach := window.Reach()
+	winMinX, winMaxX := coords.X-reach, coords.X+reach
+	winMinY, winMaxY := coords.Y-reach, coords.Y+reach
+
+	for x := winMinX; x <= winMaxX; x++ {
+		col := make([]*Dot, 0, size)
+
+		for y := winMinY; y <= winMaxY; y++ {
+			// Can be nil
+			cellValue := grid.Lookup(*NewPoint(x, y))
+
+			col = append(col, cellValue)
+		}
+
+		matrix = append(matrix, col)
+	}
+
+	window.matrix = matrix
+
+	return window
+}
+
+// Returns how many cells to each side of center the window spans
+func (w Window) Reach() int {
+	return w.size >> 1 // divide by 2, round down
+}
+
+// Returns the index number of the center cell inside window (x AND y coord of center)
+func (w Window) CenterIndex() int {
+	return w.size - w.Reach() - 1
+}
+
+// Returns the window's centerpoint's value
+func (w *Window) Center() *Dot {
+	index := w.CenterIndex()
+	return w.matrix[index][index]
+}
+
+// Returns value of arbitrary coord inside window
+func (w *Window) Get(coords Point) *Dot {
+	return w.matrix[coords.X][coords.Y]
+}
+
+// Returns the number of empty cells around the center cell
+func (w Window) NumEmptyNeighbors() int {
+
+	var count int
+
+	center := w.CenterIndex()
+
+	for x, col := range w.matrix {
+		for y, val := range col {
+			if x == center && y == center {
+				continue
+			}
+
+			if val == nil {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+func (w *Window) AliveNeighbors() []*Dot {
+	var dots []*Dot
+
+	center := w.CenterIndex()
+
+	for x, col := range w.matrix {
+		for y, val := range col {
+			if x == center && y == center {
+				continue
+			}
+
+			if val != nil {
+				dots = append(dots, val)
+			}
+		}
+	}
+
+	return dots
+}
+
+// Returns the grid coords of the center cell of the window
+func (w *Window) GridCoords() Point {
+	return w.center
+}
+
+//* -------------------------
+//* DOT
+//* -------------------------
+type Dot struct {
+	fill       color.Color
+	state      int // 1 is alive, multi-state rules use higher states for cells that are dying
+	parentGrid *Grid
+	position   Point
+}
+
+// Parsed once, since rules make new dots all the time
+var dotFill = mustParseHexColor("#adb5bd")
+
+// Set parentGrid to nil to not immediately add to a grid (in convolutions etc)
+func NewDot(coords Point, parentGrid *Grid) *Dot {
+	return NewStateDot(coords, 1, dotFill, parentGrid)
+}
+
+// Dot in any state of a multi-state rule, which decides the fill of each state
+func NewStateDot(coords Point, state int, fill color.Color, parentGrid *Grid) *Dot {
+	dot := &Dot{
+		position: coords,
+		state:    state,
+		fill:     fill,
+	}
+
+	if parentGrid != nil {
+		dot.SetParentGrid(parentGrid)
+		parentGrid.Set(coords, dot)
+	}
+
+	return dot
+}
+
+// Pretty print the Dot position x & y coordinates
+func (d Dot) String() string {
+	return fmt.Sprintf("Dot{ x: %d, y: %d }", d.Position().X, d.Position().Y)
+}
+
+// Grid will handle updating position of Dot if everything goes well
+func (d *Dot) MoveTo(coords Point) error {
+	return d.parentGrid.Move(d.position, coords, d)
+}
+
+func (d *Dot) Remove() {
+	d.parentGrid.Remove(d.position)
+}
+
+func (d *Dot) SetParentGrid(grid *Grid) {
+	d.parentGrid = grid
+}
+
+func (d *Dot) Position() Point {
+	return d.position
+}
+
+func (d *Dot) SetPosition(coords Point) {
+	d.position.SetCoords(coords.X, coords.Y)
+}
+
+func (d *Dot) Fill() color.Color {
+	return d.fill
+}
+
+func (d *Dot) State() int {
+	return d.state
+}
+
+//* -------------------------
+//* POINT
+//* -------------------------
+type Point struct {
+	X, Y int
+}
+
+func NewPoint(x, y int) *Point {
+	return &Point{X: x, Y: y}
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("{ x: %d, y: %d }", p.X, p.Y)
+}
+
+func (p *Point) SetCoords(x, y int) {
+	p.X = x
+	p.Y = y
+}
+
+func (p Point) Coords() (int, int) {
+	return p.X, p.Y
+}
+
+//* -------------------------
+//* MATRIX
+//* -------------------------
+// The storage behind a Grid
+type Matrix interface {
+	Get(coords Point) *Dot      // Never called with coords that are not InBounds
+	Set(coords Point, dot *Dot) // Setting nil empties the cell
+	InBounds(coords Point) bool
+	ForEach(callback func(dot *Dot)) // Only visits non-empty cells, column by column
+	Empty() Matrix                   // Returns a new, empty matrix of the same kind and size
+	Clone() Matrix                   // Returns a new matrix of the same kind and size, with the same dots
+}
+
+//* -------------------------
+//* SCREEN PIXEL MATRIX
+//* -------------------------
+// Indexed as [x][y], like the grid itself
+type ScreenPixelMatrix [][]*Dot
+
+// All columns share one backing slice, so big grids are a single allocation
+func NewScreenPixelMatrix(width, height int) ScreenPixelMatrix {
+	cells := make([]*Dot, width*height)
+	matrix := make(ScreenPixelMatrix, width)
+
+	for x := range matrix {
+		matrix[x] = cells[x*height : (x+1)*height : (x+1)*height]
+	}
+
+	return matrix
+}
+
+func (spm ScreenPixelMatrix) Get(coords Point) *Dot {
+	return spm[coords.X][coords.Y]
+}
+
+func (spm ScreenPixelMatrix) Set(coords Point, dot *Dot) {
+	spm[coords.X][coords.Y] = dot
+}
+
+func (spm ScreenPixelMatrix) InBounds(coords Point) bool {
+	return between(coords.X, 0, len(spm)-1) && between(coords.Y, 0, len(spm[0])-1)
+}
+
+func (spm ScreenPixelMatrix) ForEach(callback func(dot *Dot)) {
+	for _, col := range spm {
+		for _, cell := range col {
+			if cell != nil {
+				callback(cell)
+			}
+		}
+	}
+}
+
+func (spm ScreenPixelMatrix) Empty() Matrix {
+	return NewScreenPixelMatrix(len(spm), len(spm[0]))
+}
+
+func (spm ScreenPixelMatrix) Clone() Matrix {
+	clone := NewScreenPixelMatrix(len(spm), len(spm[0]))
+	for x, col := range spm {
+		copy(clone[x], col)
+	}
+
+	return clone
+}
+
+func (spm *ScreenPixelMatrix) GetAllNonEmpty() []*Dot {
+	var cells []*Dot
+
+	for _, col := range *spm {
+		for _, cell := range col {
+			if cell != nil {
+				cells = append(cells, cell)
+			}
+		}
+	}
+
+	return cells
+}
@@ -0,0 +1,77 @@
+package automaton
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Rules of every kind Grid.Convolve runs, for tests that compare ways of convolving
+var convolveTestRules = []string{
+	"B3/S23",
+	"B2/S/C3",
+	"R2,C0,M1,S3..5,B3..4,NM",
+	"B3/S23:birth=0.9,survival=0.99,noise=0.01",
+}
+
+var allBoundaries = []Boundary{Bounded, Torus, KleinBottle, Cylinder, Reflective, AliveBorder}
+
+// Returns the state of every cell of a bounded grid, 0 for empty cells
+func cellStates(grid *Grid) [][]int {
+	states := make([][]int, grid.Width())
+
+	for x := range states {
+		states[x] = make([]int, grid.Height())
+
+		for y := range states[x] {
+			if dot := grid.Lookup(Point{X: x, Y: y}); dot != nil {
+				states[x][y] = dot.State()
+			}
+		}
+	}
+
+	return states
+}
+
+// Fails at the first cell where the grids differ, or when their counts of used cells differ
+func compareGrids(t *testing.T, generation int, want, got *Grid) {
+	t.Helper()
+
+	wantStates, gotStates := cellStates(want), cellStates(got)
+	for x := range wantStates {
+		for y := range wantStates[x] {
+			if wantStates[x][y] != gotStates[x][y] {
+				t.Fatalf("generation %d: cell %v is in state %d, expected %d", generation, Point{X: x, Y: y}, gotStates[x][y], wantStates[x][y])
+			}
+		}
+	}
+
+	if got.NumUsedCells() != want.NumUsedCells() {
+		t.Fatalf("generation %d: %d used cells, expected %d", generation, got.NumUsedCells(), want.NumUsedCells())
+	}
+}
+
+func TestConvolveWorkersMatchSerial(t *testing.T) {
+	for _, rule := range convolveTestRules {
+		for _, boundary := range allBoundaries {
+			for _, workers := range []int{3, 4} {
+				t.Run(fmt.Sprintf("%s/%v/%d", rule, boundary, workers), func(t *testing.T) {
+					conv := mustParseRule(t, rule)
+
+					serial, parallel := NewGrid(23, 17), NewGrid(23, 17)
+					for _, grid := range []*Grid{serial, parallel} {
+						grid.SetBoundary(boundary)
+						randomSoup(grid, 1, 0.3)
+					}
+					parallel.SetWorkers(workers)
+
+					for generation := 1; generation <= 15; generation++ {
+						serial.Convolve(conv)
+						parallel.Convolve(conv)
+
+						compareGrids(t, generation, serial, parallel)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package automaton
+
+import (
+	"image/color"
+	"log"
+
+	"github.com/icza/gox/imagex/colorx"
+)
+
+// Includes min and max values
+func between(num, min, max int) bool {
+	return num >= min && num <= max
+}
+
+// Modulo that is never negative, e.g. mod(-1, 10) == 9
+func mod(num, divisor int) int {
+	return ((num % divisor) + divisor) % divisor
+}
+
+// Division that rounds towards negative infinity, e.g. floorDiv(-1, 10) == -1
+func floorDiv(num, divisor int) int {
+	if num < 0 {
+		return -((-num + divisor - 1) / divisor)
+	}
+
+	return num / divisor
+}
+
+func abs(num int) int {
+	if num < 0 {
+		return -num
+	}
+
+	return num
+}
+
+// Limits num to min and max (inclusive)
+func clamp(num, min, max int) int {
+	if num < min {
+		return min
+	}
+	if num > max {
+		return max
+	}
+
+	return num
+}
+
+func mustParseHexColor(hex string) color.RGBA {
+	color, err := colorx.ParseHexColor(hex)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return color
+}
+
+// Returns steps colours evenly spread from one colour to another, both included
+func Gradient(from, to color.RGBA, steps int) []color.Color {
+	gradient := make([]color.Color, steps)
+
+	for i := range gradient {
+		if steps == 1 {
+			gradient[i] = from
+			continue
+		}
+
+		t := float64(i) / float64(steps-1)
+		lerp := func(a, b uint8) uint8 {
+			return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+		}
+
+		gradient[i] = color.RGBA{R: lerp(from.R, to.R), G: lerp(from.G, to.G), B: lerp(from.B, to.B), A: lerp(from.A, to.A)}
+	}
+
+	return gradient
+}
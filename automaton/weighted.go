@@ -0,0 +1,235 @@
+package automaton
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Decides whether a cell is alive next generation, given whether it is alive now and the weighted sum of its window
+type Transition func(alive bool, sum float64) bool
+
+//* -------------------------
+//* WEIGHTED RULE
+//* -------------------------
+// Numeric kernel, where every cell in the window is multiplied by its weight, and the sum goes through a Transition
+// Live cells have a value of 1 and every other cell 0, so a weight of 0 leaves a cell out of the neighbourhood
+type WeightedRule struct {
+	Kernel
+	weights    [][]float64 // Indexed [x][y] like windows
+	taps       []weightTap // Only the weights that are not 0, so sparse kernels are cheap
+	transition Transition
+}
+
+// A single weight, as an offset from the center
+type weightTap struct {
+	offset Point
+	weight float64
+}
+
+// weights must be square with an odd size, and are indexed [x][y] like windows
+func NewWeightedRule(weights [][]float64, transition Transition) (*WeightedRule, error) {
+	size := len(weights)
+	if size%2 != 1 {
+		return nil, fmt.Errorf("weights must have an odd size, got %d", size)
+	}
+	if transition == nil {
+		return nil, errors.New("a weighted rule needs a transition")
+	}
+
+	rule := &WeightedRule{Kernel: Kernel{size: size}, weights: weights, transition: transition}
+	reach := size >> 1
+
+	for x, col := range weights {
+		if len(col) != size {
+			return nil, fmt.Errorf("weights must be square, column %d has %d weights instead of %d", x, len(col), size)
+		}
+
+		for y, weight := range col {
+			if weight != 0 {
+				rule.taps = append(rule.taps, weightTap{offset: Point{X: x - reach, Y: y - reach}, weight: weight})
+			}
+		}
+	}
+
+	return rule, nil
+}
+
+func (r *WeightedRule) ApplyKernel(win *Window) *Dot {
+	reach := win.Reach()
+
+	var sum float64
+	for _, tap := range r.taps {
+		sum += tap.weight * cellValue(win.Get(Point{X: tap.offset.X + reach, Y: tap.offset.Y + reach}))
+	}
+
+	return r.next(win.Center(), win.GridCoords(), sum)
+}
+
+// Reads only the cells with a weight straight from the grid
+func (r *WeightedRule) ApplyCell(grid *Grid, coords Point) *Dot {
+	var sum float64
+	for _, tap := range r.taps {
+		sum += tap.weight * cellValue(grid.Lookup(Point{X: coords.X + tap.offset.X, Y: coords.Y + tap.offset.Y}))
+	}
+
+	return r.next(grid.Lookup(coords), coords, sum)
+}
+
+func (r *WeightedRule) Size() int {
+	return r.size
+}
+
+// Returns the weights, indexed [x][y]
+func (r *WeightedRule) Weights() [][]float64 {
+	return r.weights
+}
+
+func (r *WeightedRule) next(center *Dot, coords Point, sum float64) *Dot {
+	alive := cellValue(center) == 1
+
+	if !r.transition(alive, sum) {
+		return nil
+	}
+	if alive {
+		return center
+	}
+
+	return NewDot(coords, nil)
+}
+
+// Live cells are 1, everything else (including dying cells of multi-state rules) is 0
+func cellValue(dot *Dot) float64 {
+	if dot != nil && dot.State() == 1 {
+		return 1
+	}
+
+	return 0
+}
+
+// Returns a Transition where dead cells are born when the sum is within birthMin and birthMax,
+// and live cells survive when it is within survivalMin and survivalMax (all inclusive)
+func SumRanges(birthMin, birthMax, survivalMin, survivalMax float64) Transition {
+	return func(alive bool, sum float64) bool {
+		if alive {
+			return sum >= survivalMin && sum <= survivalMax
+		}
+
+		return sum >= birthMin && sum <= birthMax
+	}
+}
+
+// Parses a whole weighted rule, written as its weights (see ParseWeights) and a line with the ranges of SumRanges,
+// in the same form as Larger than Life rules, e.g.
+//
+//	1 2 1
+//	2 0 2
+//	1 2 1
+//	S4..9,B5..9
+func ParseWeightedRule(text string) (*WeightedRule, error) {
+	var weightLines []string
+	var birth, survival [2]float64
+	var hasBirth, hasSurvival bool
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.ToUpper(strings.TrimSpace(line))
+		if trimmed == "" || (trimmed[0] != 'S' && trimmed[0] != 'B') {
+			weightLines = append(weightLines, line)
+			continue
+		}
+
+		for _, part := range strings.Split(strings.ReplaceAll(trimmed, " ", ""), ",") {
+			if part == "" {
+				continue
+			}
+
+			sumRange, err := parseSumRange(part[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q: %v", part, err)
+			}
+
+			switch part[0] {
+			case 'B':
+				birth, hasBirth = sumRange, true
+			case 'S':
+				survival, hasSurvival = sumRange, true
+			default:
+				return nil, fmt.Errorf("invalid range %q: expected S or B", part)
+			}
+		}
+	}
+
+	if !hasBirth || !hasSurvival {
+		return nil, errors.New("a weighted rule needs both S and B ranges")
+	}
+
+	weights, err := ParseWeights(strings.Join(weightLines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWeightedRule(weights, SumRanges(birth[0], birth[1], survival[0], survival[1]))
+}
+
+// Parses an inclusive range of sums like 4.5..9, or a single sum like 3
+func parseSumRange(value string) ([2]float64, error) {
+	bounds := strings.SplitN(value, "..", 2)
+
+	min, err := strconv.ParseFloat(bounds[0], 64)
+	if err != nil {
+		return [2]float64{}, err
+	}
+	if len(bounds) == 1 {
+		return [2]float64{min, min}, nil
+	}
+
+	max, err := strconv.ParseFloat(bounds[1], 64)
+	if err != nil {
+		return [2]float64{}, err
+	}
+
+	return [2]float64{min, max}, nil
+}
+
+// Parses weights written as rows of numbers separated by spaces, one row per line, like the kernel looks on screen
+// Empty lines and lines starting with # are skipped
+// Returns the weights indexed [x][y], ready for NewWeightedRule
+func ParseWeights(text string) ([][]float64, error) {
+	var rows [][]float64
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var row []float64
+		for _, field := range strings.Fields(line) {
+			weight, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid weight %q in row %d", field, len(rows)+1)
+			}
+
+			row = append(row, weight)
+		}
+
+		rows = append(rows, row)
+	}
+
+	// Rows are y, but weights are indexed by x first
+	weights := make([][]float64, len(rows))
+	for x := range weights {
+		weights[x] = make([]float64, len(rows))
+
+		for y, row := range rows {
+			if len(row) != len(rows) {
+				return nil, fmt.Errorf("weights must be square, row %d has %d weights instead of %d", y+1, len(row), len(rows))
+			}
+
+			weights[x][y] = row[x]
+		}
+	}
+
+	return weights, nil
+}
@@ -0,0 +1,78 @@
+package automaton
+
+import "image/color"
+
+const (
+	WIREWORLD_CONDUCTOR = 1 // The state of a plain Dot, so wires can be drawn with NewDot
+	WIREWORLD_HEAD      = 2
+	WIREWORLD_TAIL      = 3
+)
+
+var wireworldPalette = Palette{
+	{State: WIREWORLD_CONDUCTOR, Name: "conductor", Fill: mustParseHexColor("#f08c00")},
+	{State: WIREWORLD_HEAD, Name: "head", Fill: mustParseHexColor("#4dabf7")},
+	{State: WIREWORLD_TAIL, Name: "tail", Fill: mustParseHexColor("#e03131")},
+}
+
+//* -------------------------
+//* WIREWORLD
+//* -------------------------
+// Electrons (a head followed by a tail) travel along conductors, which is enough to build logic circuits
+// A conductor becomes a head when 1 or 2 of its neighbours are heads, heads become tails and tails become conductors again
+// Empty cells stay empty
+type Wireworld struct {
+	Kernel
+	fills [4]color.Color // Indexed by state
+}
+
+func NewWireworld() *Wireworld {
+	rule := &Wireworld{Kernel: Kernel{size: 3}}
+	for _, entry := range wireworldPalette {
+		rule.fills[entry.State] = entry.Fill
+	}
+
+	return rule
+}
+
+func (w *Wireworld) ApplyKernel(win *Window) *Dot {
+	center := win.Center()
+	if center == nil {
+		return nil
+	}
+
+	switch center.State() {
+	case WIREWORLD_HEAD:
+		return w.newDot(win.GridCoords(), WIREWORLD_TAIL)
+	case WIREWORLD_TAIL:
+		return w.newDot(win.GridCoords(), WIREWORLD_CONDUCTOR)
+	}
+
+	var heads int
+	for _, dot := range win.AliveNeighbors() {
+		if dot.State() == WIREWORLD_HEAD {
+			heads++
+		}
+	}
+
+	if heads == 1 || heads == 2 {
+		return w.newDot(win.GridCoords(), WIREWORLD_HEAD)
+	}
+
+	return center
+}
+
+func (w *Wireworld) Size() int {
+	return w.size
+}
+
+func (w *Wireworld) Palette() Palette {
+	return wireworldPalette
+}
+
+func (w *Wireworld) String() string {
+	return "Wireworld"
+}
+
+func (w *Wireworld) newDot(coords Point, state int) *Dot {
+	return NewStateDot(coords, state, w.fills[state], nil)
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"image/color"
+	"math"
+	"math/big"
 
+	"github.com/NormalReedus/cellular-gotomata/automaton"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/icza/gox/imagex/colorx"
 )
@@ -17,12 +21,18 @@ var (
 	bgColor, _       = colorx.ParseHexColor("#303040")
 	bgColorPaused, _ = colorx.ParseHexColor("#3f3f4a")
 	bgCellColor, _   = colorx.ParseHexColor("#022330")
+	aliveColor, _    = colorx.ParseHexColor("#adb5bd") // For simulations without dots, matches the fill of a Dot
+	activeColor      = color.RGBA{R: 0xff, G: 0xd4, B: 0x3b, A: 0x40}
+	agentColor, _    = colorx.ParseHexColor("#fa5252")
 )
 
 type Game struct {
-	grid       *Grid
-	paused     bool
-	generation int
+	sim           Simulation
+	paused        bool
+	generation    int
+	bigGeneration *big.Int        // Takes over from generation once it no longer fits in an int
+	camera        automaton.Point // The grid coords shown in the top left corner of the screen
+	showActive    bool            // Highlight the cells evaluated in the last step
 }
 
 func (g Game) BgColor() color.RGBA {
@@ -52,11 +62,15 @@ func (g *Game) Update() error {
 func (g *Game) Draw(screen *ebiten.Image) {
 	drawBackground(screen, g.BgColor())
 	drawDots(screen)
+	if g.showActive {
+		drawActiveRegion(screen)
+	}
+	drawAgents(screen)
 	drawOverlay(screen, g.BgCellColor())
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return SCREEN_WIDTH, SCREEN_HEIGHT
+	return screenSize()
 }
 
 func (g Game) Paused() bool {
@@ -75,7 +89,47 @@ func (g *Game) TogglePause() {
 	g.paused = !g.paused
 }
 
+func (g *Game) ToggleActiveRegion() {
+	g.showActive = !g.showActive
+}
+
 func (g *Game) Restart() {
 	g.generation = 0
-	g.grid.Clear()
+	g.bigGeneration = nil
+	g.sim.Clear()
+}
+
+func (g *Game) AddGenerations(num *big.Int) {
+	if g.bigGeneration == nil {
+		if num.IsInt64() && num.Int64() <= int64(math.MaxInt-g.generation) {
+			g.generation += int(num.Int64())
+			return
+		}
+
+		g.bigGeneration = big.NewInt(int64(g.generation))
+	}
+
+	g.bigGeneration.Add(g.bigGeneration, num)
+}
+
+func (g Game) Generation() string {
+	if g.bigGeneration != nil {
+		return g.bigGeneration.String()
+	}
+
+	return fmt.Sprint(g.generation)
+}
+
+func (g *Game) Pan(dx, dy int) {
+	g.camera.SetCoords(g.camera.X+dx, g.camera.Y+dy)
+}
+
+// Converts coords on the screen (in cells) to coords in the grid
+func (g Game) ViewToGrid(coords automaton.Point) automaton.Point {
+	return automaton.Point{X: coords.X + g.camera.X, Y: coords.Y + g.camera.Y}
+}
+
+// Converts coords in the grid to coords on the screen (in cells)
+func (g Game) GridToView(coords automaton.Point) automaton.Point {
+	return automaton.Point{X: coords.X - g.camera.X, Y: coords.Y - g.camera.Y}
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/NormalReedus/cellular-gotomata/automaton"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Hexagonal rules store their cells sheared into a square grid (see automaton.HexagonalRule),
+// so every row is drawn half a cell further left than the one above it, and the grid becomes a parallelogram of pointy-top hexagons
+// A hexagon is cellSize wide, and rows are cellSize*sqrt(3)/2 apart
+
+var (
+	hexagonal        bool          // Whether the rule runs on a hexagonal grid
+	hexFillImage     *ebiten.Image // White hexagon, coloured in when drawn
+	hexOutlineImage  *ebiten.Image // White hexagon border, for grid lines
+	hexImageCellSize int           // The cellSize the images were made for
+)
+
+// Returns the height of a hexagon, which is a bit more than its width
+func hexHeight() float64 {
+	return float64(cellSize) * 2 / math.Sqrt(3)
+}
+
+// Returns the distance between the centers of two rows
+func hexRowHeight() float64 {
+	return float64(cellSize) * math.Sqrt(3) / 2
+}
+
+// Returns the size of the drawn hexagonal grid in pixels
+func hexScreenSize() (int, int) {
+	width := float64(cellSize) * (float64(gridWidth) + float64(gridHeight-1)/2)
+	height := hexRowHeight()*float64(gridHeight-1) + hexHeight()
+
+	return int(math.Ceil(width)), int(math.Ceil(height))
+}
+
+// Returns the top left corner (in pixels) of the hexagon of a cell on the screen
+func hexOrigin(coords automaton.Point) (float64, float64) {
+	x := float64(cellSize) * (float64(coords.X) - float64(coords.Y)/2 + float64(gridHeight-1)/2)
+	y := hexRowHeight() * float64(coords.Y)
+
+	return x, y
+}
+
+// Returns the cell on the screen of the hexagon under a pixel
+func pixelToHex(x, y int) automaton.Point {
+	// Fractional row, and column as if the rows were not shifted
+	row := (float64(y) - hexHeight()/2) / hexRowHeight()
+	column := (float64(x)-float64(cellSize)/2)/float64(cellSize) - float64(gridHeight-1)/2
+
+	// In cube coords the three axes add up to 0, so the one that rounded worst is put back from the other two
+	cubeX, cubeZ := column-row/2, row
+	cubeY := -cubeX - cubeZ
+
+	roundX, roundY, roundZ := math.Round(cubeX), math.Round(cubeY), math.Round(cubeZ)
+	diffX, diffY, diffZ := math.Abs(roundX-cubeX), math.Abs(roundY-cubeY), math.Abs(roundZ-cubeZ)
+
+	switch {
+	case diffX > diffY && diffX > diffZ:
+		roundX = -roundY - roundZ
+	case diffY <= diffZ:
+		roundZ = -roundX - roundY
+	}
+
+	return automaton.Point{X: int(roundX + roundZ), Y: int(roundZ)}
+}
+
+// Returns the cell on the screen under a pixel, for either topology
+func pixelToCell(x, y int) automaton.Point {
+	if hexagonal {
+		return pixelToHex(x, y)
+	}
+
+	return automaton.Point{X: x / cellSize, Y: y / cellSize}
+}
+
+func drawHex(screen *ebiten.Image, coords automaton.Point, fill color.Color) {
+	updateHexImages()
+
+	x, y := hexOrigin(coords)
+	r, g, b, a := fill.RGBA()
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(x, y)
+	op.ColorM.Scale(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff, float64(a)/0xffff)
+
+	screen.DrawImage(hexFillImage, op)
+}
+
+// Draws the border of every hexagon on the screen
+func drawHexGridLines(screen *ebiten.Image, lineColor color.RGBA) {
+	updateHexImages()
+
+	op := &ebiten.DrawImageOptions{}
+	op.ColorM.Scale(float64(lineColor.R)/0xff, float64(lineColor.G)/0xff, float64(lineColor.B)/0xff, float64(lineColor.A)/0xff)
+
+	for column := 0; column < gridWidth; column++ {
+		for row := 0; row < gridHeight; row++ {
+			x, y := hexOrigin(automaton.Point{X: column, Y: row})
+
+			op.GeoM.Reset()
+			op.GeoM.Translate(x, y)
+			screen.DrawImage(hexOutlineImage, op)
+		}
+	}
+}
+
+// Draws the hexagon images again whenever the cell size changes
+func updateHexImages() {
+	if hexFillImage != nil && hexImageCellSize == cellSize {
+		return
+	}
+
+	width, height := cellSize, int(math.Ceil(hexHeight()))
+
+	hexFillImage = ebiten.NewImage(width, height)
+	fillHexagon(hexFillImage, 0, ebiten.CompositeModeSourceOver)
+
+	// The outline is a full hexagon with a slightly smaller one cut out of it
+	hexOutlineImage = ebiten.NewImage(width, height)
+	fillHexagon(hexOutlineImage, 0, ebiten.CompositeModeSourceOver)
+	fillHexagon(hexOutlineImage, 1, ebiten.CompositeModeClear)
+
+	hexImageCellSize = cellSize
+}
+
+// Fills a pointy-top hexagon that touches the edges of the image, minus inset pixels on every side
+func fillHexagon(image *ebiten.Image, inset float64, mode ebiten.CompositeMode) {
+	centerX, centerY := float64(cellSize)/2, hexHeight()/2
+	radius := hexHeight()/2 - inset*2/math.Sqrt(3)
+
+	// A fan of triangles around the center
+	vertices := []ebiten.Vertex{{DstX: float32(centerX), DstY: float32(centerY)}}
+	var indices []uint16
+
+	for corner := 0; corner < 6; corner++ {
+		angle := math.Pi / 180 * float64(30+60*corner)
+		vertices = append(vertices, ebiten.Vertex{
+			DstX: float32(centerX + radius*math.Cos(angle)),
+			DstY: float32(centerY + radius*math.Sin(angle)),
+		})
+		indices = append(indices, 0, uint16(corner+1), uint16((corner+1)%6+1))
+	}
+
+	for i := range vertices {
+		vertices[i].SrcX, vertices[i].SrcY = 1, 1
+		vertices[i].ColorR, vertices[i].ColorG, vertices[i].ColorB, vertices[i].ColorA = 1, 1, 1, 1
+	}
+
+	op := &ebiten.DrawTrianglesOptions{CompositeMode: mode}
+	image.DrawTriangles(vertices, indices, whitePixel(), op)
+}
+
+// Source image for DrawTriangles, which needs one even when every vertex has its own colour
+func whitePixel() *ebiten.Image {
+	pixel := ebiten.NewImage(3, 3)
+	pixel.Fill(color.White)
+
+	return pixel
+}
@@ -1,23 +1,34 @@
 package main
 
 import (
+	"github.com/NormalReedus/cellular-gotomata/automaton"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
-func leftClick() *Point {
+func leftClick() *automaton.Point {
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		x, y := ebiten.CursorPosition()
-		return &Point{X: x / CELL_SIZE, Y: y / CELL_SIZE}
+		coords := pixelToCell(ebiten.CursorPosition())
+		return &coords
 	}
 
 	return nil
 }
 
-func rightClick() *Point {
+// Like leftClick, but for every frame the button is held down
+func leftHeld() *automaton.Point {
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		coords := pixelToCell(ebiten.CursorPosition())
+		return &coords
+	}
+
+	return nil
+}
+
+func rightClick() *automaton.Point {
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
-		x, y := ebiten.CursorPosition()
-		return &Point{X: x / CELL_SIZE, Y: y / CELL_SIZE}
+		coords := pixelToCell(ebiten.CursorPosition())
+		return &coords
 	}
 
 	return nil
@@ -30,3 +41,54 @@ func spaceKey() bool {
 func cKey() bool {
 	return inpututil.IsKeyJustPressed(ebiten.KeyC)
 }
+
+func bKey() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyB)
+}
+
+// Returns the direction of the held arrow keys, e.g. (-1, 0) for left
+func arrowKeys() (int, int) {
+	var dx, dy int
+
+	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) {
+		dx--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) {
+		dx++
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowUp) {
+		dy--
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) {
+		dy++
+	}
+
+	return dx, dy
+}
+
+func plusKey() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadAdd)
+}
+
+func minusKey() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadSubtract)
+}
+
+func aKey() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyA)
+}
+
+func pKey() bool {
+	return inpututil.IsKeyJustPressed(ebiten.KeyP)
+}
+
+// Returns the number key (1-9) that was just pressed, or 0
+func numberKey() int {
+	for number := 1; number <= 9; number++ {
+		if inpututil.IsKeyJustPressed(ebiten.Key0+ebiten.Key(number)) || inpututil.IsKeyJustPressed(ebiten.KeyNumpad0+ebiten.Key(number)) {
+			return number
+		}
+	}
+
+	return 0
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"image/color"
 	_ "image/png" // necessary for loading images
 	"log"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/NormalReedus/cellular-gotomata/automaton"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
@@ -15,26 +23,414 @@ import (
 //TODO: figure out how to easily define a pixel size that everything multiplies by, so we can have cells, that are actually many pixels (which allows us to draw borders on every cell, so you can see the grid)
 
 const (
-	GRID_WIDTH, GRID_HEIGHT     = 60, 60 //* BOTH MUST BE DIVISIBLE BY CELL_SIZE
-	CELL_SIZE                   = 20
-	SCREEN_WIDTH, SCREEN_HEIGHT = GRID_WIDTH * CELL_SIZE, GRID_HEIGHT * CELL_SIZE
+	DEFAULT_GRID_WIDTH, DEFAULT_GRID_HEIGHT = 60, 60
+	DEFAULT_CELL_SIZE                       = 20
+	MAX_WINDOW_SIZE                         = 1200 // Bigger screens are scaled down to fit the window
+	MIN_GRID_LINE_CELL_SIZE                 = 4    // Grid lines would hide the dots on smaller cells
+	MIN_CONTINUOUS_VALUE                    = 0.01 // Continuous cells below this are not drawn
+	PALETTE_SWATCH_SIZE, PALETTE_SWATCH_GAP = 16, 6
 )
 
 var (
 	game *Game
-	gol  Convolver
+	gol  automaton.Convolver
 	// golMod Convolver
+
+	gridWidth, gridHeight int
+	cellSize              int
+	boundaryName          string
+	engineName            string
+	workers               int
+	tracking              bool
+	compileLUT            bool
+	ruleString            string
+	weightsPath           string
+	gollyPath             string
+	neighborhoodSpec      string
+	colormapName          string
+	palettePath           string
+	seedRow               string
+	randomSeed            int64
 )
 
 func init() {
-	rand.Seed(time.Now().UnixNano())
-	ebiten.SetWindowSize(SCREEN_WIDTH, SCREEN_HEIGHT)
 	ebiten.SetWindowTitle("Cellular Automata")
+
+	flag.IntVar(&gridWidth, "width", DEFAULT_GRID_WIDTH, "number of cells horizontally (the visible part of unbounded grids)")
+	flag.IntVar(&gridHeight, "height", DEFAULT_GRID_HEIGHT, "number of cells vertically (the visible part of unbounded grids)")
+	flag.IntVar(&cellSize, "cell", DEFAULT_CELL_SIZE, "size of every cell in pixels")
+	flag.StringVar(&boundaryName, "boundary", automaton.Bounded.String(), "what lies past the grid edges: bounded, torus, klein, cylinder, reflect or alive")
+	flag.IntVar(&workers, "workers", runtime.NumCPU(), "number of goroutines sharing every step of the dense engine")
+	flag.StringVar(&ruleString, "rule", "", "rule string such as B36/S23, 23/3, B2-a/S12, B2/S/C3, B2/S34H (hexagonal), B1/S1V (von Neumann), B3/S23:birth=0.9,noise=0.001 (probabilistic), Wireworld or HighLife (defaults to the built in CustomGame2)")
+	flag.StringVar(&weightsPath, "weights", "", "file with a weighted rule: rows of weights followed by sum ranges like S4..9,B5..9 (replaces -rule)")
+	flag.StringVar(&gollyPath, "golly", "", "Golly rule file to load: a .rule with a @TABLE or @TREE section, a .table or a .tree (replaces -rule)")
+	flag.StringVar(&neighborhoodSpec, "neighborhood", "", "neighbourhood a Life-like -rule counts over: moore, vonneumann, extended, cross, checkerboard or hexagonal (add a reach like moore2), or a file with a mask of # and .")
+	flag.BoolVar(&compileLUT, "lut", false, "compile the rule into a lookup table first (3x3 binary rules only), and print the table")
+	flag.BoolVar(&tracking, "track", true, "only evaluate the cells around last generation's changes (dense and sparse engines, show them with A)")
+	flag.StringVar(&engineName, "engine", "dense", "how cells are stored: dense, sparse for an unbounded grid, hashlife for an unbounded quadtree (pan with the arrow keys), bitgrid for bit-packed Life-like rules, continuous for Lenia and SmoothLife (-rule orbium, smoothlife or lenia:R=13,mu=0.15,...), turmite for agents like Langton's ant (-rule langton, RL or {{{1,2,0},{0,8,0}}}), elementary for one dimensional rules (-rule 30 or T52,R2 for totalistic ones), margolus for 2x2 block rules (-rule critters, tron, bbm, sand or MS,D0;8;4;3;2;5;9;7;1;6;10;11;12;13;14;15) or sand for a falling-sand sandbox (hold the left mouse button to pour)")
+	flag.StringVar(&palettePath, "palette", "", "file the palette of a multi-state rule (like wireworld) is loaded from if it exists, and saved to with P (defaults to one per rule, like palette-wireworld.txt)")
+	flag.Int64Var(&randomSeed, "seed", 0, "seed for everything random, so a run can be repeated exactly (picks one from the clock when left out)")
+	flag.StringVar(&seedRow, "row", "1", "first row of the elementary engine as 0s and 1s, centered (or random)")
+	flag.StringVar(&colormapName, "colormap", "viridis", "colours of continuous cells: viridis, inferno or gray")
 }
 
 func setupInitialState() {
-	game = &Game{grid: NewGrid(), paused: true}
-	gol = NewCustomGame2()
+	flag.Parse()
+
+	// Any number is a seed, so only a missing -seed picks one from the clock
+	seedSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedSet = true
+		}
+	})
+	if !seedSet {
+		randomSeed = time.Now().UnixNano()
+	}
+
+	boundary, err := automaton.ParseBoundary(boundaryName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Only the dense, sparse, hashlife and bitgrid engines run Convolvers, the rest read -rule themselves
+	switch engineName {
+	case "dense", "sparse", "hashlife", "bitgrid":
+		gol = newRule()
+		hexagonal = automaton.IsHexagonal(gol)
+	}
+
+	game = &Game{sim: newSimulation(boundary), paused: true}
+	loadPalette()
+
+	ebiten.SetWindowSize(windowSize())
+}
+
+// Replaces the rule's palette with the saved one, if there is one
+// Palettes with states the rule does not have are ignored, since those cells would never evolve
+func loadPalette() {
+	sim, ok := game.sim.(StatePainter)
+	if !ok || sim.Palette() == nil {
+		return
+	}
+
+	path := paletteFile()
+
+	text, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	palette, err := automaton.ParsePalette(string(text))
+	if err != nil {
+		log.Fatalf("invalid palette %s: %v", path, err)
+	}
+
+	if err := sim.SetPalette(palette); err != nil {
+		log.Printf("ignoring palette %s: %v", path, err)
+	}
+}
+
+func savePalette() {
+	sim, ok := game.sim.(StatePainter)
+	if !ok || sim.Palette() == nil {
+		return
+	}
+
+	path := paletteFile()
+
+	if err := os.WriteFile(path, []byte(sim.Palette().String()), 0644); err != nil {
+		log.Printf("could not save palette: %v", err)
+		return
+	}
+
+	log.Printf("saved palette to %s", path)
+}
+
+// Returns -palette, or a file named after the rule (or the engine, for engines without one),
+// so the palettes of different rules do not overwrite each other
+func paletteFile() string {
+	if palettePath != "" {
+		return palettePath
+	}
+
+	name := engineName
+	if gol != nil {
+		name = fmt.Sprint(gol)
+	}
+
+	// Rules like B2/S/C3 are not file names
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+
+		return '-'
+	}, name)
+
+	return "palette-" + name + ".txt"
+}
+
+// Returns the rule given by -rule, -weights, -golly, -neighborhood and -lut
+func newRule() automaton.Convolver {
+	var conv automaton.Convolver = automaton.NewCustomGame2()
+
+	if ruleString != "" {
+		rule, err := automaton.ParseRule(ruleString)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		conv = rule
+	}
+
+	if weightsPath != "" {
+		text, err := os.ReadFile(weightsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		rule, err := automaton.ParseWeightedRule(string(text))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		conv = rule
+	}
+
+	if gollyPath != "" {
+		text, err := os.ReadFile(gollyPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// The file name is the rule's name, unless the file has a @RULE line
+		name := strings.TrimSuffix(filepath.Base(gollyPath), filepath.Ext(gollyPath))
+
+		rule, err := automaton.ParseGollyRule(name, string(text))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		conv = rule
+	}
+
+	if neighborhoodSpec != "" {
+		lifeLike, ok := conv.(*automaton.LifeLikeRule)
+		if !ok {
+			log.Fatalf("-neighborhood only works with Life-like rules like B3/S23, got %v", conv)
+		}
+
+		// A file with a mask, or the spec itself
+		spec := neighborhoodSpec
+		if text, err := os.ReadFile(spec); err == nil {
+			spec = string(text)
+		}
+
+		neighborhood, err := automaton.ParseNeighborhood(spec)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if conv, err = lifeLike.WithNeighborhood(neighborhood); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if compileLUT {
+		lut, err := automaton.CompileLUT(conv)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Printf("rule lookup table: %v", lut)
+		conv = lut
+	}
+
+	return conv
+}
+
+func newSimulation(boundary automaton.Boundary) Simulation {
+	switch engineName {
+	case "dense":
+		grid := automaton.NewGrid(gridWidth, gridHeight)
+		grid.SetBoundary(boundary)
+		grid.SetSeed(randomSeed)
+		grid.SetWorkers(workers)
+		grid.SetActiveTracking(tracking)
+
+		return NewGridSimulation(grid, gol)
+
+	case "sparse":
+		grid := automaton.NewSparseGrid()
+		grid.SetSeed(randomSeed)
+		grid.SetActiveTracking(tracking)
+
+		return NewGridSimulation(grid, gol)
+
+	case "hashlife":
+		sim, err := NewHashLifeSimulation(gol)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return sim
+
+	case "bitgrid":
+		bitGrid, err := automaton.NewBitGrid(gridWidth, gridHeight, gol)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := bitGrid.SetBoundary(boundary); err != nil {
+			log.Fatal(err)
+		}
+
+		return NewBitGridSimulation(bitGrid)
+
+	case "continuous":
+		if ruleString == "" {
+			ruleString = "orbium"
+		}
+
+		rule, err := automaton.ParseContinuousRule(ruleString)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		colormap, err := automaton.ParseColormap(colormapName)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		grid := automaton.NewContinuousGrid(gridWidth, gridHeight)
+		grid.SetSeed(randomSeed)
+
+		return NewContinuousSimulation(grid, rule, colormap)
+
+	case "turmite":
+		if ruleString == "" {
+			ruleString = "langton"
+		}
+
+		rule, err := automaton.ParseTurmite(ruleString)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		grid := automaton.NewGrid(gridWidth, gridHeight)
+		grid.SetBoundary(boundary)
+		grid.SetSeed(randomSeed)
+
+		// Start with a single agent in the middle, clicks add more
+		sim := NewAgentSimulation(grid, rule)
+		sim.Set(automaton.Point{X: gridWidth / 2, Y: gridHeight / 2})
+
+		return sim
+
+	case "elementary":
+		if ruleString == "" {
+			ruleString = "30"
+		}
+
+		rule, err := automaton.ParseOneDimensionalRule(ruleString)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		grid := automaton.NewGrid(gridWidth, gridHeight)
+		grid.SetBoundary(boundary)
+		grid.SetSeed(randomSeed)
+
+		spacetime, err := automaton.NewSpacetime(grid, rule)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return NewSpacetimeSimulation(spacetime, newSeedRow(grid.Rand()))
+
+	case "margolus":
+		if ruleString == "" {
+			ruleString = "critters"
+		}
+
+		rule, err := automaton.ParseMargolusRule(ruleString)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		grid := automaton.NewGrid(gridWidth, gridHeight)
+		grid.SetBoundary(boundary)
+		grid.SetSeed(randomSeed)
+
+		partition, err := automaton.NewMargolusPartition(grid, rule)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return NewMargolusSimulation(partition)
+
+	case "sand":
+		grid := automaton.NewGrid(gridWidth, gridHeight)
+		grid.SetBoundary(boundary)
+		grid.SetSeed(randomSeed)
+
+		sandbox, err := automaton.NewSandbox(grid)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return NewSandboxSimulation(sandbox)
+	}
+
+	log.Fatalf("unknown engine %q", engineName)
+	return nil
+}
+
+// Returns the first row given by -row, random rows are drawn from rng
+func newSeedRow(rng *rand.Rand) []bool {
+	if seedRow == "random" {
+		seed := make([]bool, gridWidth)
+		for x := range seed {
+			seed[x] = rng.Intn(2) == 1
+		}
+
+		return seed
+	}
+
+	seed, err := automaton.ParseSeedRow(seedRow)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return seed
+}
+
+// Returns the size of the drawn grid in pixels
+func screenSize() (int, int) {
+	if hexagonal {
+		return hexScreenSize()
+	}
+
+	return gridWidth * cellSize, gridHeight * cellSize
+}
+
+// Returns the screen size scaled down to fit within MAX_WINDOW_SIZE, keeping the aspect ratio
+func windowSize() (int, int) {
+	width, height := screenSize()
+
+	largest := width
+	if height > largest {
+		largest = height
+	}
+
+	if largest <= MAX_WINDOW_SIZE {
+		return width, height
+	}
+
+	return width * MAX_WINDOW_SIZE / largest, height * MAX_WINDOW_SIZE / largest
 }
 
 func main() {
@@ -48,19 +444,17 @@ func main() {
 // Default TPS
 func inputUpdate() {
 	//TODO: make these handlers into functions
-	coords := leftClick()
-	if coords != nil {
-		NewDot(*coords, game.grid)
+	if sim, ok := game.sim.(BrushPainter); ok {
+		if coords := leftHeld(); coords != nil {
+			sim.Paint(game.ViewToGrid(*coords))
+		}
+	} else if coords := leftClick(); coords != nil {
+		game.sim.Set(game.ViewToGrid(*coords))
 	}
 
-	coords = rightClick()
+	coords := rightClick()
 	if coords != nil {
-		dot, err := game.grid.Get(*coords)
-		if dot == nil || err != nil {
-			return
-		}
-
-		dot.Remove()
+		game.sim.Remove(game.ViewToGrid(*coords))
 	}
 
 	if spaceKey() {
@@ -70,6 +464,37 @@ func inputUpdate() {
 	if cKey() {
 		game.Restart()
 	}
+
+	if sim, ok := game.sim.(*GridSimulation); ok && bKey() {
+		sim.Grid().SetBoundary(sim.Grid().Boundary().Next())
+	}
+
+	if aKey() {
+		game.ToggleActiveRegion()
+	}
+
+	if sim, ok := game.sim.(StatePainter); ok {
+		if number := numberKey(); number > 0 {
+			sim.Select(number - 1)
+		}
+		if pKey() {
+			savePalette()
+		}
+	}
+
+	if sim, ok := game.sim.(SpeedAdjuster); ok {
+		if plusKey() {
+			sim.Faster()
+		}
+		if minusKey() {
+			sim.Slower()
+		}
+	}
+
+	// Only unbounded grids have anything to see outside of the view
+	if game.sim.Unbounded() {
+		game.Pan(arrowKeys())
+	}
 }
 
 // Slower TPS
@@ -78,10 +503,7 @@ func gameUpdate() {
 		return
 	}
 
-	// Doing more convolutions per tick can 'modify' an existing Game of Life to compose brand new games
-	game.grid.Convolve(gol)
-
-	game.generation++
+	game.AddGenerations(game.sim.Step())
 }
 
 func drawBackground(screen *ebiten.Image, clr color.RGBA) {
@@ -89,20 +511,95 @@ func drawBackground(screen *ebiten.Image, clr color.RGBA) {
 }
 
 func drawDots(screen *ebiten.Image) {
-	game.grid.ForEach(func(dot *Dot) {
-		dot.Draw(screen)
+	min := game.ViewToGrid(automaton.Point{})
+	max := game.ViewToGrid(automaton.Point{X: gridWidth - 1, Y: gridHeight - 1})
+
+	game.sim.ForEachVisible(min, max, func(coords automaton.Point, fill color.Color) {
+		drawDot(screen, game.GridToView(coords), fill)
 	})
 }
 
-func drawOverlay(screen *ebiten.Image, bgCellColor color.RGBA) {
+func drawActiveRegion(screen *ebiten.Image) {
+	sim, ok := game.sim.(ActiveRegionReporter)
+	if !ok {
+		return
+	}
+
+	min := game.ViewToGrid(automaton.Point{})
+	max := game.ViewToGrid(automaton.Point{X: gridWidth - 1, Y: gridHeight - 1})
+
+	sim.ForEachActive(min, max, func(coords automaton.Point) {
+		drawDot(screen, game.GridToView(coords), activeColor)
+	})
+}
+
+// coords are on the screen (in cells)
+func drawAgents(screen *ebiten.Image) {
+	sim, ok := game.sim.(AgentReporter)
+	if !ok {
+		return
+	}
+
+	min := game.ViewToGrid(automaton.Point{})
+	max := game.ViewToGrid(automaton.Point{X: gridWidth - 1, Y: gridHeight - 1})
 
-	for x := 0; x < SCREEN_WIDTH; x += CELL_SIZE {
-		ebitenutil.DrawLine(screen, float64(x), 0, float64(x), float64(SCREEN_HEIGHT), bgCellColor)
+	sim.ForEachAgent(min, max, func(coords automaton.Point) {
+		drawDot(screen, game.GridToView(coords), agentColor)
+	})
+}
+
+func drawDot(screen *ebiten.Image, coords automaton.Point, fill color.Color) {
+	if hexagonal {
+		drawHex(screen, coords, fill)
+		return
 	}
-	for y := 0; y < SCREEN_HEIGHT; y += CELL_SIZE {
-		ebitenutil.DrawLine(screen, 0, float64(y), float64(SCREEN_HEIGHT), float64(y), bgCellColor)
+
+	x, y := coords.Coords()
+	ebitenutil.DrawRect(screen, float64(x*cellSize), float64(y*cellSize), float64(cellSize), float64(cellSize), fill)
+}
+
+func drawOverlay(screen *ebiten.Image, bgCellColor color.RGBA) {
+	if cellSize >= MIN_GRID_LINE_CELL_SIZE {
+		drawGridLines(screen, bgCellColor)
+	}
+
+	if sim, ok := game.sim.(StatePainter); ok {
+		drawPalette(screen, sim)
 	}
 
 	// Print generation num
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("Generation: %d", game.generation))
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Generation: %s\nSeed: %d\n%s", game.Generation(), randomSeed, game.sim.Status()))
+}
+
+// Draws a swatch for every state of the palette in the top right corner, the selected one with a border
+func drawPalette(screen *ebiten.Image, sim StatePainter) {
+	palette := sim.Palette()
+	screenWidth, _ := screenSize()
+
+	for i, entry := range palette {
+		x := float64(screenWidth - (len(palette)-i)*(PALETTE_SWATCH_SIZE+PALETTE_SWATCH_GAP))
+		y := float64(PALETTE_SWATCH_GAP)
+
+		if i == sim.Selected() {
+			ebitenutil.DrawRect(screen, x-2, y-2, PALETTE_SWATCH_SIZE+4, PALETTE_SWATCH_SIZE+4, color.White)
+		}
+
+		ebitenutil.DrawRect(screen, x, y, PALETTE_SWATCH_SIZE, PALETTE_SWATCH_SIZE, entry.Fill)
+	}
+}
+
+func drawGridLines(screen *ebiten.Image, bgCellColor color.RGBA) {
+	if hexagonal {
+		drawHexGridLines(screen, bgCellColor)
+		return
+	}
+
+	screenWidth, screenHeight := screenSize()
+
+	for x := 0; x < screenWidth; x += cellSize {
+		ebitenutil.DrawLine(screen, float64(x), 0, float64(x), float64(screenHeight), bgCellColor)
+	}
+	for y := 0; y < screenHeight; y += cellSize {
+		ebitenutil.DrawLine(screen, 0, float64(y), float64(screenWidth), float64(y), bgCellColor)
+	}
 }
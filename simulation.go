@@ -0,0 +1,638 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math/big"
+
+	"github.com/NormalReedus/cellular-gotomata/automaton"
+)
+
+// The model behind the screen, which the frontend steps, edits and draws
+type Simulation interface {
+	Step() *big.Int // Returns the number of generations that were advanced
+	Set(coords automaton.Point)
+	Remove(coords automaton.Point)
+	Clear()
+	Unbounded() bool
+	ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color))
+	Status() string // Extra lines for the overlay
+}
+
+// Simulations that can advance more than one generation per step
+type SpeedAdjuster interface {
+	Faster()
+	Slower()
+}
+
+// Simulations with more states than alive or dead, where Set paints the selected state
+type StatePainter interface {
+	Palette() automaton.Palette
+	SetPalette(palette automaton.Palette) error // Fails for palettes with states the rule does not have
+	Selected() int                              // Index into the palette
+	Select(index int)
+}
+
+// Simulations that keep painting while the left mouse button is held down, instead of once per click
+type BrushPainter interface {
+	Paint(coords automaton.Point)
+}
+
+// Simulations with agents walking over the cells
+type AgentReporter interface {
+	ForEachAgent(min, max automaton.Point, callback func(coords automaton.Point))
+}
+
+// Simulations that can show which cells they evaluated in the last step
+type ActiveRegionReporter interface {
+	ForEachActive(min, max automaton.Point, callback func(coords automaton.Point))
+}
+
+//* -------------------------
+//* GRID SIMULATION
+//* -------------------------
+// Convolves a grid with a rule, one generation per step
+type GridSimulation struct {
+	grid     *automaton.Grid
+	rule     automaton.Convolver
+	palette  automaton.Palette // Only for multi-state rules
+	selected int
+}
+
+func NewGridSimulation(grid *automaton.Grid, rule automaton.Convolver) *GridSimulation {
+	sim := &GridSimulation{grid: grid, rule: rule}
+	if multiState, ok := rule.(automaton.MultiStateRule); ok {
+		sim.palette = multiState.Palette()
+	}
+
+	return sim
+}
+
+func (s *GridSimulation) Grid() *automaton.Grid {
+	return s.grid
+}
+
+func (s *GridSimulation) Step() *big.Int {
+	// Doing more convolutions per tick can 'modify' an existing Game of Life to compose brand new games
+	s.grid.Convolve(s.rule)
+
+	return big.NewInt(1)
+}
+
+func (s *GridSimulation) Set(coords automaton.Point) {
+	// Clicks can land outside of bounded grids, when the window is scaled
+	if _, err := s.grid.Get(coords); err != nil {
+		return
+	}
+
+	if s.palette == nil {
+		automaton.NewDot(coords, s.grid)
+		return
+	}
+
+	// Clicking a cell that already has the selected state cycles it to the next one instead
+	entry := s.palette[s.selected]
+	if dot, _ := s.grid.Get(coords); dot != nil && dot.State() == entry.State {
+		entry = s.palette[s.palette.Next(entry.State)]
+	}
+
+	automaton.NewStateDot(coords, entry.State, entry.Fill, s.grid)
+}
+
+func (s *GridSimulation) Remove(coords automaton.Point) {
+	dot, err := s.grid.Get(coords)
+	if dot == nil || err != nil {
+		return
+	}
+
+	dot.Remove()
+}
+
+func (s *GridSimulation) Clear() {
+	s.grid.Restart()
+}
+
+func (s *GridSimulation) Unbounded() bool {
+	return s.grid.Unbounded()
+}
+
+func (s *GridSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.grid.ForEach(func(dot *automaton.Dot) {
+		coords := dot.Position()
+		if coords.X < min.X || coords.Y < min.Y || coords.X > max.X || coords.Y > max.Y {
+			return
+		}
+
+		// The palette can have other fills than the rule
+		if fill, ok := s.palette.Fill(dot.State()); ok {
+			callback(coords, fill)
+			return
+		}
+
+		callback(coords, dot.Fill())
+	})
+}
+
+func (s *GridSimulation) ForEachActive(min, max automaton.Point, callback func(coords automaton.Point)) {
+	for _, coords := range s.grid.ActiveRegion() {
+		if coords.X < min.X || coords.Y < min.Y || coords.X > max.X || coords.Y > max.Y {
+			continue
+		}
+
+		callback(coords)
+	}
+}
+
+func (s *GridSimulation) Status() string {
+	status := fmt.Sprintf("Boundary: %v\nActive cells: %d", s.grid.Boundary(), len(s.grid.ActiveRegion()))
+	if s.palette != nil {
+		status += fmt.Sprintf("\nPaint: %s (1-%d to pick, P to save)", s.palette[s.selected].Name, len(s.palette))
+	}
+
+	return status
+}
+
+func (s *GridSimulation) Palette() automaton.Palette {
+	return s.palette
+}
+
+// Loaded palettes only apply to multi-state rules, since plain dots have no other states to paint
+func (s *GridSimulation) SetPalette(palette automaton.Palette) error {
+	multiState, ok := s.rule.(automaton.MultiStateRule)
+	if !ok {
+		return fmt.Errorf("%v has no states to paint", s.rule)
+	}
+
+	if err := palette.CheckStates(multiState.Palette()); err != nil {
+		return err
+	}
+
+	s.palette = palette
+	s.selected = 0
+
+	return nil
+}
+
+func (s *GridSimulation) Selected() int {
+	return s.selected
+}
+
+func (s *GridSimulation) Select(index int) {
+	if index >= 0 && index < len(s.palette) {
+		s.selected = index
+	}
+}
+
+//* -------------------------
+//* HASHLIFE SIMULATION
+//* -------------------------
+// Advances an unbounded HashLife universe 2^stepExponent generations per step
+type HashLifeSimulation struct {
+	hashlife *automaton.HashLife
+	stopped  error // Why the last step could not be taken, shown until the next one succeeds
+}
+
+func NewHashLifeSimulation(rule automaton.Convolver) (*HashLifeSimulation, error) {
+	hashlife, err := automaton.NewHashLife(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HashLifeSimulation{hashlife: hashlife}, nil
+}
+
+func (s *HashLifeSimulation) Step() *big.Int {
+	if s.stopped = s.hashlife.Step(); s.stopped != nil {
+		return new(big.Int)
+	}
+
+	return s.hashlife.StepSize()
+}
+
+func (s *HashLifeSimulation) Set(coords automaton.Point) {
+	s.hashlife.Set(coords, true)
+}
+
+func (s *HashLifeSimulation) Remove(coords automaton.Point) {
+	s.hashlife.Set(coords, false)
+}
+
+func (s *HashLifeSimulation) Clear() {
+	s.hashlife.Clear()
+	s.stopped = nil
+}
+
+func (s *HashLifeSimulation) Unbounded() bool {
+	return true
+}
+
+func (s *HashLifeSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.hashlife.ForEachAlive(min, max, func(coords automaton.Point) {
+		callback(coords, aliveColor)
+	})
+}
+
+func (s *HashLifeSimulation) Status() string {
+	status := fmt.Sprintf("Step: 2^%d\nPopulation: %d", s.hashlife.StepExponent(), s.hashlife.Population())
+	if s.stopped != nil {
+		status += fmt.Sprintf("\nStopped: %v", s.stopped)
+	}
+
+	return status
+}
+
+func (s *HashLifeSimulation) Faster() {
+	s.hashlife.SetStepExponent(s.hashlife.StepExponent() + 1)
+}
+
+func (s *HashLifeSimulation) Slower() {
+	if s.hashlife.StepExponent() == 0 {
+		return
+	}
+
+	s.hashlife.SetStepExponent(s.hashlife.StepExponent() - 1)
+}
+
+//* -------------------------
+//* BIT GRID SIMULATION
+//* -------------------------
+// Steps outer-totalistic rules on a bit-packed grid, one generation per step
+type BitGridSimulation struct {
+	bitGrid *automaton.BitGrid
+}
+
+func NewBitGridSimulation(bitGrid *automaton.BitGrid) *BitGridSimulation {
+	return &BitGridSimulation{bitGrid: bitGrid}
+}
+
+func (s *BitGridSimulation) Step() *big.Int {
+	s.bitGrid.Step()
+
+	return big.NewInt(1)
+}
+
+func (s *BitGridSimulation) Set(coords automaton.Point) {
+	s.bitGrid.Set(coords, true)
+}
+
+func (s *BitGridSimulation) Remove(coords automaton.Point) {
+	s.bitGrid.Set(coords, false)
+}
+
+func (s *BitGridSimulation) Clear() {
+	s.bitGrid.Clear()
+}
+
+func (s *BitGridSimulation) Unbounded() bool {
+	return false
+}
+
+func (s *BitGridSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.bitGrid.ForEachAlive(func(coords automaton.Point) {
+		callback(coords, aliveColor)
+	})
+}
+
+func (s *BitGridSimulation) Status() string {
+	return fmt.Sprintf("Boundary: %v\nPopulation: %d", s.bitGrid.Boundary(), s.bitGrid.Population())
+}
+
+//* -------------------------
+//* CONTINUOUS SIMULATION
+//* -------------------------
+// Steps a continuous rule like Lenia, drawing every cell through a colormap
+type ContinuousSimulation struct {
+	grid     *automaton.ContinuousGrid
+	rule     automaton.ContinuousRule
+	colormap automaton.Colormap
+	brush    int // Radius painted around a click
+}
+
+func NewContinuousSimulation(grid *automaton.ContinuousGrid, rule automaton.ContinuousRule, colormap automaton.Colormap) *ContinuousSimulation {
+	// A patch about the size of a kernel gives the rule something to work with
+	var brush int
+	for _, kernel := range rule.Kernels() {
+		if kernel.Radius() > brush {
+			brush = kernel.Radius()
+		}
+	}
+
+	return &ContinuousSimulation{grid: grid, rule: rule, colormap: colormap, brush: brush}
+}
+
+func (s *ContinuousSimulation) Step() *big.Int {
+	s.grid.Step(s.rule)
+
+	return big.NewInt(1)
+}
+
+// Paints a disk of random values
+func (s *ContinuousSimulation) Set(coords automaton.Point) {
+	s.paint(coords, s.grid.Rand().Float64)
+}
+
+func (s *ContinuousSimulation) Remove(coords automaton.Point) {
+	s.paint(coords, func() float64 { return 0 })
+}
+
+func (s *ContinuousSimulation) paint(center automaton.Point, value func() float64) {
+	for x := -s.brush; x <= s.brush; x++ {
+		for y := -s.brush; y <= s.brush; y++ {
+			if x*x+y*y <= s.brush*s.brush {
+				s.grid.Set(automaton.Point{X: center.X + x, Y: center.Y + y}, value())
+			}
+		}
+	}
+}
+
+func (s *ContinuousSimulation) Clear() {
+	s.grid.Restart()
+}
+
+func (s *ContinuousSimulation) Unbounded() bool {
+	return false
+}
+
+func (s *ContinuousSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.grid.ForEach(func(coords automaton.Point, value float64) {
+		// Nearly empty cells are left to the background
+		if value < MIN_CONTINUOUS_VALUE {
+			return
+		}
+
+		callback(coords, s.colormap.At(value))
+	})
+}
+
+func (s *ContinuousSimulation) Status() string {
+	return fmt.Sprintf("Rule: %v\nMass: %.1f", s.rule, s.grid.Mass())
+}
+
+//* -------------------------
+//* AGENT SIMULATION
+//* -------------------------
+// Moves turmites over a grid, one tick per step, clicks add and remove agents
+type AgentSimulation struct {
+	layer *automaton.AgentLayer
+	rule  *automaton.TurmiteRule
+}
+
+func NewAgentSimulation(grid *automaton.Grid, rule *automaton.TurmiteRule) *AgentSimulation {
+	return &AgentSimulation{layer: automaton.NewAgentLayer(grid), rule: rule}
+}
+
+func (s *AgentSimulation) Step() *big.Int {
+	s.layer.Step()
+
+	return big.NewInt(1)
+}
+
+func (s *AgentSimulation) Set(coords automaton.Point) {
+	if _, err := s.layer.Grid().Get(coords); err != nil {
+		return
+	}
+
+	s.layer.Add(automaton.NewAgent(coords, automaton.North, s.rule))
+}
+
+// Removes the agents at coords, or the cell if there are none
+func (s *AgentSimulation) Remove(coords automaton.Point) {
+	agents := len(s.layer.Agents())
+	if s.layer.RemoveAt(coords); len(s.layer.Agents()) < agents {
+		return
+	}
+
+	if dot, err := s.layer.Grid().Get(coords); dot != nil && err == nil {
+		dot.Remove()
+	}
+}
+
+func (s *AgentSimulation) Clear() {
+	s.layer.Grid().Restart()
+	s.layer.Clear()
+}
+
+func (s *AgentSimulation) Unbounded() bool {
+	return s.layer.Grid().Unbounded()
+}
+
+func (s *AgentSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.layer.Grid().ForEach(func(dot *automaton.Dot) {
+		coords := dot.Position()
+		if coords.X < min.X || coords.Y < min.Y || coords.X > max.X || coords.Y > max.Y {
+			return
+		}
+
+		callback(coords, dot.Fill())
+	})
+}
+
+func (s *AgentSimulation) ForEachAgent(min, max automaton.Point, callback func(coords automaton.Point)) {
+	for _, agent := range s.layer.Agents() {
+		coords := agent.Position()
+		if coords.X < min.X || coords.Y < min.Y || coords.X > max.X || coords.Y > max.Y {
+			continue
+		}
+
+		callback(coords)
+	}
+}
+
+func (s *AgentSimulation) Status() string {
+	return fmt.Sprintf("Turmite: %v\nAgents: %d", s.rule, len(s.layer.Agents()))
+}
+
+//* -------------------------
+//* SPACETIME SIMULATION
+//* -------------------------
+// Runs a one dimensional rule, one row per step, with time going down the screen
+type SpacetimeSimulation struct {
+	spacetime *automaton.Spacetime
+	seed      []bool
+}
+
+func NewSpacetimeSimulation(spacetime *automaton.Spacetime, seed []bool) *SpacetimeSimulation {
+	spacetime.Seed(seed)
+
+	return &SpacetimeSimulation{spacetime: spacetime, seed: seed}
+}
+
+func (s *SpacetimeSimulation) Step() *big.Int {
+	s.spacetime.Step()
+
+	return big.NewInt(1)
+}
+
+// Clicks only change the latest generation, since the rows above are history
+func (s *SpacetimeSimulation) Set(coords automaton.Point) {
+	coords.Y = s.spacetime.Row()
+	if _, err := s.spacetime.Grid().Get(coords); err != nil {
+		return
+	}
+
+	automaton.NewDot(coords, s.spacetime.Grid())
+}
+
+func (s *SpacetimeSimulation) Remove(coords automaton.Point) {
+	coords.Y = s.spacetime.Row()
+	if dot, err := s.spacetime.Grid().Get(coords); dot != nil && err == nil {
+		dot.Remove()
+	}
+}
+
+// Starts over from the seed
+func (s *SpacetimeSimulation) Clear() {
+	s.spacetime.Seed(s.seed)
+}
+
+func (s *SpacetimeSimulation) Unbounded() bool {
+	return false
+}
+
+func (s *SpacetimeSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.spacetime.Grid().ForEach(func(dot *automaton.Dot) {
+		callback(dot.Position(), dot.Fill())
+	})
+}
+
+func (s *SpacetimeSimulation) Status() string {
+	return fmt.Sprintf("Rule: %v\nBoundary: %v", s.spacetime.Rule(), s.spacetime.Grid().Boundary())
+}
+
+//* -------------------------
+//* MARGOLUS SIMULATION
+//* -------------------------
+// Runs a block rule, which shifts its blocks every step
+type MargolusSimulation struct {
+	partition *automaton.MargolusPartition
+}
+
+func NewMargolusSimulation(partition *automaton.MargolusPartition) *MargolusSimulation {
+	return &MargolusSimulation{partition: partition}
+}
+
+func (s *MargolusSimulation) Step() *big.Int {
+	s.partition.Step()
+
+	return big.NewInt(1)
+}
+
+func (s *MargolusSimulation) Set(coords automaton.Point) {
+	if _, err := s.partition.Grid().Get(coords); err != nil {
+		return
+	}
+
+	automaton.NewDot(coords, s.partition.Grid())
+}
+
+func (s *MargolusSimulation) Remove(coords automaton.Point) {
+	if dot, err := s.partition.Grid().Get(coords); dot != nil && err == nil {
+		dot.Remove()
+	}
+}
+
+func (s *MargolusSimulation) Clear() {
+	s.partition.Restart()
+}
+
+func (s *MargolusSimulation) Unbounded() bool {
+	return false
+}
+
+func (s *MargolusSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.partition.Grid().ForEach(func(dot *automaton.Dot) {
+		callback(dot.Position(), dot.Fill())
+	})
+}
+
+func (s *MargolusSimulation) Status() string {
+	return fmt.Sprintf("Block rule: %v\nBlock phase: %d\nBoundary: %v", s.partition.Rule(), s.partition.Phase(), s.partition.Grid().Boundary())
+}
+
+//* -------------------------
+//* SANDBOX SIMULATION
+//* -------------------------
+// Falling-sand game, where the left mouse button pours the selected material
+type SandboxSimulation struct {
+	sandbox  *automaton.Sandbox
+	palette  automaton.Palette
+	selected int
+}
+
+func NewSandboxSimulation(sandbox *automaton.Sandbox) *SandboxSimulation {
+	return &SandboxSimulation{sandbox: sandbox, palette: sandbox.Palette()}
+}
+
+func (s *SandboxSimulation) Step() *big.Int {
+	s.sandbox.Step()
+
+	return big.NewInt(1)
+}
+
+func (s *SandboxSimulation) Set(coords automaton.Point) {
+	s.sandbox.Paint(coords, automaton.Material(s.palette[s.selected].State))
+}
+
+// Pours the selected material on the cells around coords as well, so the brush is 3x3
+func (s *SandboxSimulation) Paint(coords automaton.Point) {
+	for x := coords.X - 1; x <= coords.X+1; x++ {
+		for y := coords.Y - 1; y <= coords.Y+1; y++ {
+			s.Set(automaton.Point{X: x, Y: y})
+		}
+	}
+}
+
+func (s *SandboxSimulation) Remove(coords automaton.Point) {
+	if dot, err := s.sandbox.Grid().Get(coords); dot != nil && err == nil {
+		dot.Remove()
+	}
+}
+
+func (s *SandboxSimulation) Clear() {
+	s.sandbox.Restart()
+}
+
+func (s *SandboxSimulation) Unbounded() bool {
+	return false
+}
+
+func (s *SandboxSimulation) ForEachVisible(min, max automaton.Point, callback func(coords automaton.Point, fill color.Color)) {
+	s.sandbox.Grid().ForEach(func(dot *automaton.Dot) {
+		// The palette can have other fills than the materials
+		if fill, ok := s.palette.Fill(dot.State()); ok {
+			callback(dot.Position(), fill)
+			return
+		}
+
+		callback(dot.Position(), dot.Fill())
+	})
+}
+
+func (s *SandboxSimulation) Status() string {
+	return fmt.Sprintf("Particles: %d\nPaint: %s (1-%d to pick, P to save)", s.sandbox.Grid().NumUsedCells(), s.palette[s.selected].Name, len(s.palette))
+}
+
+func (s *SandboxSimulation) Palette() automaton.Palette {
+	return s.palette
+}
+
+func (s *SandboxSimulation) SetPalette(palette automaton.Palette) error {
+	if err := palette.CheckStates(s.sandbox.Palette()); err != nil {
+		return err
+	}
+
+	s.palette = palette
+	s.selected = 0
+
+	return nil
+}
+
+func (s *SandboxSimulation) Selected() int {
+	return s.selected
+}
+
+func (s *SandboxSimulation) Select(index int) {
+	if index >= 0 && index < len(s.palette) {
+		s.selected = index
+	}
+}
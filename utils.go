@@ -8,8 +8,3 @@ package main
 
 // 	return image
 // }
-
-// Includes min and max values
-func between(num, min, max int) bool {
-	return num >= min && num <= max
-}